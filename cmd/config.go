@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/config"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+type configValidateCmd struct {
+	logger log.Logger
+	Print  bool `long:"print" description:"Print the config with the defaults applied"`
+}
+
+func (self *configValidateCmd) Execute(args []string) error {
+	cfg, err := config.ParseConfig(self.logger, options.Config)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return errors.Wrap(err, "invalid config")
+	}
+	if self.Print {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(cfg); err != nil {
+			return errors.Wrap(err, "writing config")
+		}
+	}
+	level.Info(self.logger).Log("msg", "config is valid", "path", options.Config)
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/bsc/bsciotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/eth/ethiotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/poly/polyiotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/config"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/ethereum"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+type dryRunCmd struct {
+	logger log.Logger
+	Side   string `long:"side" required:"true" choice:"ethiotex" choice:"iotexeth" choice:"polyiotex" choice:"iotexpoly" choice:"bsciotex" choice:"iotexbsc" description:"Tracker of the bridge side to run"`
+	Output string `long:"output" short:"o" description:"Output file of the json report. Defaults to stdout"`
+	blockRange
+}
+
+// dryRunReport is everything the trackers would have recorded.
+type dryRunReport struct {
+	Side      string              `json:"side"`
+	Bridge    types.Bridge        `json:"bridge"`
+	Network   types.Network       `json:"network"`
+	Ranges    []dryRunRange       `json:"ranges"`
+	Totals    []dryRunTokenTotal  `json:"totals"`
+	Transfers []types.Transaction `json:"transfers"`
+	// TVL of the tokens locked on the network now, only for the sides with a token safe.
+	TVL []types.TVLData `json:"tvl,omitempty"`
+}
+
+type dryRunRange struct {
+	FromBlock uint64             `json:"fromBlock"`
+	ToBlock   uint64             `json:"toBlock"`
+	Events    int                `json:"events"`
+	Tokens    []dryRunTokenTotal `json:"tokens"`
+}
+
+type dryRunTokenTotal struct {
+	Symbol string  `json:"symbol"`
+	Token  string  `json:"token"`
+	Events int     `json:"events"`
+	Amount float64 `json:"amount"`
+	Fee    float64 `json:"fee"`
+}
+
+// noPrices is the price source of the tvl readers in a dry run,
+// the prices aren't fetched so the tvl has no usd value.
+type noPrices struct{}
+
+func (noPrices) Latest(symbol string, maxAge time.Duration) (float64, error) {
+	return 0, errors.New("prices aren't fetched in a dry run")
+}
+
+type tvlReader interface {
+	ReadTVL() []types.TVLData
+}
+
+// newTVLReader creates the tvl tracker of the bridge side, nil for the sides without a token safe.
+func newTVLReader(ctx context.Context, logger log.Logger, name string, cfg *config.Config, client ethereum.Client, prices bridge.PriceSource) (tvlReader, error) {
+	var (
+		r   tvlReader
+		err error
+	)
+	switch name {
+	case ethiotex.ComponentName:
+		r, err = ethiotex.NewTVLTracker(ctx, client, logger, cfg.EthIoTeX, nil, prices)
+	case polyiotex.ComponentName:
+		r, err = polyiotex.NewTVLTracker(ctx, client, logger, cfg.PolyIoTeX, nil, prices)
+	case bsciotex.ComponentName:
+		r, err = bsciotex.NewTVLTracker(ctx, client, logger, cfg.BscIoTeX, nil, prices)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %v tvl tracker", name)
+	}
+	return r, nil
+}
+
+// Execute runs the tx tracker, and the tvl tracker, of the bridge side over the range
+// and writes what they would record as json. Nothing is read from or written to the store.
+func (self *dryRunCmd) Execute(args []string) error {
+	ctx := context.Background()
+	cfg, err := config.ParseConfig(self.logger, options.Config)
+	if err != nil {
+		return errors.Wrap(err, "creating config")
+	}
+	nodes, err := newNodes(ctx, self.logger, cfg.Nodes, []types.Network{sideNetworks[self.Side]})
+	if err != nil {
+		return errors.Wrap(err, "creating node clients")
+	}
+	t, err := newSideTracker(ctx, self.logger, self.Side, cfg, nodes, nil)
+	if err != nil {
+		return err
+	}
+	client := nodes[t.network]
+	fromBlock, toBlock, err := self.blocks(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	report := dryRunReport{
+		Side:      self.Side,
+		Bridge:    t.bridge,
+		Network:   t.network,
+		Ranges:    make([]dryRunRange, 0),
+		Transfers: make([]types.Transaction, 0),
+	}
+	for start := fromBlock; start <= toBlock; start += self.Batch {
+		end := start + self.Batch - 1
+		if end > toBlock {
+			end = toBlock
+		}
+		txs, err := t.tracker.Traverse(new(big.Int).SetUint64(start), new(big.Int).SetUint64(end))
+		if err != nil {
+			return errors.Wrapf(err, "traversing blocks from:%v to:%v", start, end)
+		}
+		report.Ranges = append(report.Ranges, dryRunRange{
+			FromBlock: start,
+			ToBlock:   end,
+			Events:    len(txs),
+			Tokens:    tokenTotals(txs),
+		})
+		report.Transfers = append(report.Transfers, txs...)
+	}
+	report.Totals = tokenTotals(report.Transfers)
+
+	tvl, err := newTVLReader(ctx, self.logger, self.Side, cfg, client, noPrices{})
+	if err != nil {
+		return err
+	}
+	if tvl != nil {
+		report.TVL = tvl.ReadTVL()
+	}
+
+	var out io.Writer = os.Stdout
+	if self.Output != "" {
+		f, err := os.Create(self.Output)
+		if err != nil {
+			return errors.Wrap(err, "creating output file")
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.Wrap(err, "writing report")
+	}
+	level.Info(self.logger).Log("msg", "dry run done", "side", self.Side, "fromBlock", fromBlock, "toBlock", toBlock, "events", len(report.Transfers))
+	// The summary goes to stderr so it doesn't mix with the report.
+	return printDryRunSummary(os.Stderr, report)
+}
+
+// tokenTotals sums the transfers of every token, sorted by symbol.
+func tokenTotals(txs []types.Transaction) []dryRunTokenTotal {
+	totals := make(map[string]*dryRunTokenTotal)
+	for _, tx := range txs {
+		total, ok := totals[tx.Token]
+		if !ok {
+			total = &dryRunTokenTotal{Symbol: tx.Symbol, Token: tx.Token}
+			totals[tx.Token] = total
+		}
+		total.Events++
+		total.Amount += tx.Amount
+		total.Fee += tx.Fee
+	}
+	out := make([]dryRunTokenTotal, 0, len(totals))
+	for _, total := range totals {
+		out = append(out, *total)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Symbol != out[j].Symbol {
+			return out[i].Symbol < out[j].Symbol
+		}
+		return out[i].Token < out[j].Token
+	})
+	return out
+}
+
+func printDryRunSummary(w io.Writer, report dryRunReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FROM BLOCK\tTO BLOCK\tSYMBOL\tEVENTS\tAMOUNT\tFEE")
+	for _, r := range report.Ranges {
+		if len(r.Tokens) == 0 {
+			fmt.Fprintf(tw, "%v\t%v\t-\t0\t0\t0\n", r.FromBlock, r.ToBlock)
+		}
+		for _, t := range r.Tokens {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", r.FromBlock, r.ToBlock, t.Symbol, t.Events, t.Amount, t.Fee)
+		}
+	}
+	for _, t := range report.Totals {
+		fmt.Fprintf(tw, "total\t\t%v\t%v\t%v\t%v\n", t.Symbol, t.Events, t.Amount, t.Fee)
+	}
+	if len(report.TVL) > 0 {
+		fmt.Fprintln(tw, "\nSYMBOL\tTOKEN\tTVL")
+		for _, p := range report.TVL {
+			fmt.Fprintf(tw, "%v\t%v\t%v\n", p.Symbol, p.Token, p.Value)
+		}
+	}
+	return tw.Flush()
+}
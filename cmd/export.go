@@ -0,0 +1,87 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/export"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+type exportCmd struct {
+	logger  log.Logger
+	Kind    string `long:"kind" required:"true" choice:"transfers" choice:"tvl" choice:"prices" description:"Data to export"`
+	Format  string `long:"format" default:"csv" choice:"csv" choice:"ndjson" description:"Output format"`
+	From    string `long:"from" required:"true" description:"Start of the range, as 2006-01-02 or RFC3339"`
+	To      string `long:"to" description:"End of the range, as 2006-01-02 or RFC3339. Defaults to now"`
+	Bridge  string `long:"bridge" description:"Only export the given bridge"`
+	Side    string `long:"side" description:"Only export the given bridge side"`
+	Network string `long:"network" description:"Only export the given network"`
+	Symbol  string `long:"symbol" description:"Only export the given symbol"`
+	Output  string `long:"output" short:"o" description:"Output file. Defaults to stdout"`
+	Gzip    bool   `long:"gzip" description:"Compress the output with gzip"`
+}
+
+func (self *exportCmd) Execute(args []string) error {
+	from, to, err := parseRange(self.From, self.To)
+	if err != nil {
+		return err
+	}
+	q := types.Query{
+		From:    from,
+		To:      to,
+		Bridge:  types.Bridge(self.Bridge),
+		Side:    types.BridgeSide(self.Side),
+		Network: types.Network(self.Network),
+		Symbol:  self.Symbol,
+	}
+	if err := export.ValidateQuery(self.Kind, q); err != nil {
+		return err
+	}
+	_, store, tsdb, err := newStore(self.logger)
+	if err != nil {
+		return err
+	}
+	defer tsdb.Close()
+
+	var (
+		out io.Writer = os.Stdout
+		f   *os.File
+		gz  *gzip.Writer
+	)
+	if self.Output != "" {
+		f, err = os.Create(self.Output)
+		if err != nil {
+			return errors.Wrap(err, "creating output file")
+		}
+		out = f
+	}
+	if self.Gzip {
+		gz = gzip.NewWriter(out)
+		out = gz
+	}
+
+	rows, err := export.Write(context.Background(), store, out, self.Kind, self.Format, q)
+	// The gzip writer is closed before the file so its footer is written,
+	// and the close errors are returned since a failed close can lose the end of the export.
+	if gz != nil {
+		if cerr := gz.Close(); cerr != nil && err == nil {
+			err = errors.Wrap(cerr, "closing gzip writer")
+		}
+	}
+	if f != nil {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = errors.Wrap(cerr, "closing output file")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	level.Info(self.logger).Log("msg", "exported", "kind", self.Kind, "rows", rows)
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/bsc/bsciotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/eth/iotexeth"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/poly/polyiotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/ethereum"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/health"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// nodeURLEnvs are the env vars with the node urls of every network.
+var nodeURLEnvs = map[types.Network]string{
+	types.NetEthereum: ethereum.NodeURLEnvName,
+	types.NetIoTeX:    iotexeth.NodeUrlKey,
+	types.NetPolygon:  polyiotex.NodeUrlKey,
+	types.NetBsc:      bsciotex.NodeUrlKey,
+}
+
+// newNodes connects to the nodes of the given networks,
+// so only the env vars of the networks used by the command are needed.
+// The env var of every network can list several node urls separated by commas,
+// the calls go to the healthiest one and fail over to the others.
+// Nodes that can't be dialed are left out, it fails only when a network has no node.
+// The clients record the metrics of the rpc calls.
+func newNodes(ctx context.Context, logger log.Logger, cfg ethereum.PoolConfig, networks []types.Network) (map[types.Network]ethereum.Client, error) {
+	nodes := make(map[types.Network]ethereum.Client, len(networks))
+	for _, network := range networks {
+		env, ok := nodeURLEnvs[network]
+		if !ok {
+			return nil, errors.Errorf("unknown network:%v", network)
+		}
+		endpoints := make([]ethereum.Endpoint, 0)
+		for _, url := range strings.Split(os.Getenv(env), ",") {
+			url = strings.TrimSpace(url)
+			if url == "" {
+				continue
+			}
+			var (
+				client ethereum.Client
+				err    error
+			)
+			// Ethereum nodes are checked to not be syncing.
+			if network == types.NetEthereum {
+				client, err = ethereum.Dial(ctx, logger, url)
+			} else {
+				client, err = ethclient.DialContext(ctx, url)
+			}
+			if err != nil {
+				level.Error(logger).Log("msg", "creating node client, leaving it out", "network", network, "endpoint", ethereum.EndpointName(url), "err", err)
+				continue
+			}
+			endpoints = append(endpoints, ethereum.Endpoint{Name: ethereum.EndpointName(url), Client: client})
+		}
+		pool, err := ethereum.NewPool(logger, network, cfg, endpoints)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating %v node pool from env:%v", network, env)
+		}
+		go pool.Run(ctx)
+		nodes[network] = ethereum.NewInstrumentedClient(string(network), pool)
+	}
+	return nodes, nil
+}
+
+func headReaders(nodes map[types.Network]ethereum.Client) map[types.Network]health.HeadReader {
+	readers := make(map[types.Network]health.HeadReader, len(nodes))
+	for network, node := range nodes {
+		readers[network] = node
+	}
+	return readers
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/config"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/price"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/pkg/errors"
+)
+
+type priceBackfillCmd struct {
+	logger     log.Logger
+	Symbols    []string `long:"symbol" description:"Symbol to backfill, can be repeated. Defaults to all known symbols"`
+	From       string   `long:"from" required:"true" description:"Start of the range, as 2006-01-02 or RFC3339"`
+	To         string   `long:"to" description:"End of the range, as 2006-01-02 or RFC3339. Defaults to now"`
+	Resolution string   `long:"resolution" default:"hourly" choice:"hourly" choice:"daily" description:"Resolution of the loaded prices"`
+}
+
+func (self *priceBackfillCmd) Execute(args []string) error {
+	from, to, err := parseRange(self.From, self.To)
+	if err != nil {
+		return err
+	}
+	cfg, store, tsdb, err := newStore(self.logger)
+	if err != nil {
+		return err
+	}
+	defer tsdb.Close()
+
+	tracker, err := price.New(self.logger, context.Background(), store, cfg.Price)
+	if err != nil {
+		return errors.Wrap(err, "creating price tracker")
+	}
+	assets, err := tracker.Assets(self.Symbols...)
+	if err != nil {
+		return errors.Wrap(err, "getting assets")
+	}
+	return tracker.Backfill(assets, from, to, self.Resolution)
+}
+
+type recomputeUSDCmd struct {
+	logger log.Logger
+	From   string `long:"from" required:"true" description:"Start of the range, as 2006-01-02 or RFC3339"`
+	To     string `long:"to" description:"End of the range, as 2006-01-02 or RFC3339. Defaults to now"`
+}
+
+func (self *recomputeUSDCmd) Execute(args []string) error {
+	from, to, err := parseRange(self.From, self.To)
+	if err != nil {
+		return err
+	}
+	_, store, tsdb, err := newStore(self.logger)
+	if err != nil {
+		return err
+	}
+	defer tsdb.Close()
+
+	updated, err := store.RecomputeTxsUSD(from, to)
+	if err != nil {
+		return errors.Wrap(err, "recomputing usd values")
+	}
+	level.Info(self.logger).Log("msg", "usd values recomputed", "txs", updated)
+	return nil
+}
+
+// newStore parses the config and creates the bridge store.
+func newStore(logger log.Logger) (*config.Config, *bridge.Store, influxdb2.Client, error) {
+	cfg, err := config.ParseConfig(logger, options.Config)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "creating config")
+	}
+	tsdb := influxdb2.NewClient(os.Getenv("INFLUXDB_URL"), os.Getenv("INFLUXDB_TOKEN"))
+	store, err := bridge.NewSore(context.Background(), logger, cfg.Bridge, tsdb)
+	if err != nil {
+		tsdb.Close()
+		return nil, nil, nil, errors.Wrap(err, "creating bridge store")
+	}
+	return cfg, store, tsdb, nil
+}
+
+// parseRange parses the from and to flags, an empty to means now.
+func parseRange(fromFlag, toFlag string) (time.Time, time.Time, error) {
+	from, err := parseTime(fromFlag)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Wrap(err, "parsing from")
+	}
+	to := time.Now()
+	if toFlag != "" {
+		to, err = parseTime(toFlag)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrap(err, "parsing to")
+		}
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.Errorf("from:%v must be before to:%v", from, to)
+	}
+	return from, to, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if !strings.Contains(value, "T") {
+		return time.Parse("2006-01-02", value)
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/bsc/bsciotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/bsc/iotexbsc"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/eth/ethiotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/eth/iotexeth"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/poly/iotexpoly"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/poly/polyiotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/config"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/ethereum"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+type transfersBackfillCmd struct {
+	logger log.Logger
+	Side   string `long:"side" required:"true" choice:"ethiotex" choice:"iotexeth" choice:"polyiotex" choice:"iotexpoly" choice:"bsciotex" choice:"iotexbsc" description:"Tracker of the bridge side to re-index"`
+	blockRange
+}
+
+// blockRange are the flags of the commands that scan a block range.
+type blockRange struct {
+	FromBlock uint64 `long:"from-block" description:"First block of the range"`
+	ToBlock   uint64 `long:"to-block" description:"Last block of the range"`
+	From      string `long:"from" description:"Start of the range, as 2006-01-02 or RFC3339. Used when the blocks aren't given"`
+	To        string `long:"to" description:"End of the range, as 2006-01-02 or RFC3339. Defaults to now"`
+	Batch     uint64 `long:"batch" default:"999" description:"Blocks scanned at once"`
+}
+
+// traverser scans a block range of a bridge side.
+type traverser interface {
+	Traverse(fromBlockNo, toBlockNo *big.Int) ([]types.Transaction, error)
+}
+
+// sideTracker is the tx tracker of a bridge side and the network it watches.
+type sideTracker struct {
+	tracker traverser
+	bridge  types.Bridge
+	side    types.BridgeSide
+	network types.Network
+	// peer is the other network of the bridge.
+	peer types.Network
+	// cashier emits the receipts from its start block.
+	cashier    common.Address
+	startBlock uint64
+}
+
+// sideNetworks are the networks watched by the trackers of the bridge sides.
+var sideNetworks = map[string]types.Network{
+	ethiotex.ComponentName:  types.NetEthereum,
+	iotexeth.ComponentName:  types.NetIoTeX,
+	polyiotex.ComponentName: types.NetPolygon,
+	iotexpoly.ComponentName: types.NetIoTeX,
+	bsciotex.ComponentName:  types.NetBsc,
+	iotexbsc.ComponentName:  types.NetIoTeX,
+}
+
+func newSideTracker(ctx context.Context, logger log.Logger, name string, cfg *config.Config, nodes map[types.Network]ethereum.Client, store *bridge.Store) (*sideTracker, error) {
+	var (
+		t   = &sideTracker{}
+		err error
+	)
+	switch name {
+	case ethiotex.ComponentName:
+		t.bridge, t.side, t.network, t.peer = types.EthereumIoteX, types.FromLeft, types.NetEthereum, types.NetIoTeX
+		t.cashier, t.startBlock = ethiotex.TokenCashierAddress, ethiotex.TokenCashierStartBlockNo
+		t.tracker, err = ethiotex.NewTransactionTracker(ctx, nodes[t.network], logger, cfg.EthIoTeX, store)
+	case iotexeth.ComponentName:
+		t.bridge, t.side, t.network, t.peer = types.EthereumIoteX, types.FromRight, types.NetIoTeX, types.NetEthereum
+		t.cashier, t.startBlock = iotexeth.TokenCashierAddress, iotexeth.TokenCashierStartBlockNo
+		t.tracker, err = iotexeth.NewTransactionTracker(ctx, nodes[t.network], logger, cfg.IoTeXEth, store)
+	case polyiotex.ComponentName:
+		t.bridge, t.side, t.network, t.peer = types.PolygonIoteX, types.FromLeft, types.NetPolygon, types.NetIoTeX
+		t.cashier, t.startBlock = polyiotex.TokenCashierAddress, polyiotex.TokenCashierStartBlockNo
+		t.tracker, err = polyiotex.NewTransactionTracker(ctx, nodes[t.network], logger, cfg.PolyIoTeX, store)
+	case iotexpoly.ComponentName:
+		t.bridge, t.side, t.network, t.peer = types.PolygonIoteX, types.FromRight, types.NetIoTeX, types.NetPolygon
+		t.cashier, t.startBlock = iotexpoly.TokenCashierAddress, iotexpoly.TokenCashierStartBlockNo
+		t.tracker, err = iotexpoly.NewTransactionTracker(ctx, nodes[t.network], logger, cfg.IoTeXPoly, store)
+	case bsciotex.ComponentName:
+		t.bridge, t.side, t.network, t.peer = types.BscIoteX, types.FromLeft, types.NetBsc, types.NetIoTeX
+		t.cashier, t.startBlock = bsciotex.TokenCashierAddress, bsciotex.TokenCashierStartBlockNo
+		t.tracker, err = bsciotex.NewTransactionTracker(ctx, nodes[t.network], logger, cfg.BscIoTeX, store)
+	case iotexbsc.ComponentName:
+		t.bridge, t.side, t.network, t.peer = types.BscIoteX, types.FromRight, types.NetIoTeX, types.NetBsc
+		t.cashier, t.startBlock = iotexbsc.TokenCashierAddress, iotexbsc.TokenCashierStartBlockNo
+		t.tracker, err = iotexbsc.NewTransactionTracker(ctx, nodes[t.network], logger, cfg.IoTeXBsc, store)
+	default:
+		return nil, errors.Errorf("unknown bridge side:%v", name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %v tx tracker", name)
+	}
+	return t, nil
+}
+
+func (self *transfersBackfillCmd) Execute(args []string) error {
+	ctx := context.Background()
+	cfg, store, tsdb, err := newStore(self.logger)
+	if err != nil {
+		return err
+	}
+	defer tsdb.Close()
+	nodes, err := newNodes(ctx, self.logger, cfg.Nodes, []types.Network{sideNetworks[self.Side]})
+	if err != nil {
+		return errors.Wrap(err, "creating node clients")
+	}
+	t, err := newSideTracker(ctx, self.logger, self.Side, cfg, nodes, store)
+	if err != nil {
+		return err
+	}
+	client := nodes[t.network]
+
+	fromBlock, toBlock, err := self.blocks(ctx, client)
+	if err != nil {
+		return err
+	}
+	level.Info(self.logger).Log("msg", "re-indexing transfers", "side", self.Side, "fromBlock", fromBlock, "toBlock", toBlock)
+
+	var (
+		total       bridge.ReindexResult
+		first, last time.Time
+	)
+	for start := fromBlock; start <= toBlock; start += self.Batch {
+		end := start + self.Batch - 1
+		if end > toBlock {
+			end = toBlock
+		}
+		txs, err := t.tracker.Traverse(new(big.Int).SetUint64(start), new(big.Int).SetUint64(end))
+		if err != nil {
+			return errors.Wrapf(err, "traversing blocks from:%v to:%v", start, end)
+		}
+		from, err := blockTime(ctx, client, start)
+		if err != nil {
+			return err
+		}
+		to, err := blockTime(ctx, client, end)
+		if err != nil {
+			return err
+		}
+		if first.IsZero() {
+			first = from
+		}
+		last = to
+		res, err := store.ReplaceTransfers(ctx, types.Query{
+			From:   from,
+			To:     to.Add(time.Second),
+			Bridge: t.bridge,
+			Side:   t.side,
+		}, start, end, txs)
+		if err != nil {
+			return errors.Wrapf(err, "replacing transfers of blocks from:%v to:%v", start, end)
+		}
+		level.Info(self.logger).Log("msg", "blocks re-indexed", "fromBlock", start, "toBlock", end,
+			"added", res.Added, "changed", res.Changed, "removed", res.Removed, "unchanged", res.Unchanged)
+		total.Added += res.Added
+		total.Changed += res.Changed
+		total.Removed += res.Removed
+		total.Unchanged += res.Unchanged
+	}
+	level.Info(self.logger).Log("msg", "transfers re-indexed", "side", self.Side,
+		"added", total.Added, "changed", total.Changed, "removed", total.Removed, "unchanged", total.Unchanged)
+
+	// The written transfers are valued with the recorded prices at their time.
+	if total.Added+total.Changed > 0 {
+		updated, err := store.RecomputeTxsUSD(first, last.Add(time.Second))
+		if err != nil {
+			return errors.Wrap(err, "recomputing usd values")
+		}
+		level.Info(self.logger).Log("msg", "usd values recomputed", "txs", updated)
+	}
+	return nil
+}
+
+// blocks returns the blocks given by the flags or the blocks of the time range.
+func (self *blockRange) blocks(ctx context.Context, client ethereum.Client) (uint64, uint64, error) {
+	if self.Batch == 0 {
+		return 0, 0, errors.New("batch needs to be positive")
+	}
+	if self.FromBlock > 0 || self.ToBlock > 0 {
+		if self.From != "" || self.To != "" {
+			return 0, 0, errors.New("the range is either blocks or times")
+		}
+		if self.FromBlock > self.ToBlock {
+			return 0, 0, errors.Errorf("from block:%v must not be after to block:%v", self.FromBlock, self.ToBlock)
+		}
+		return self.FromBlock, self.ToBlock, nil
+	}
+	if self.From == "" {
+		return 0, 0, errors.New("the range needs the blocks or the times")
+	}
+	from, to, err := parseRange(self.From, self.To)
+	if err != nil {
+		return 0, 0, err
+	}
+	fromBlock, err := bridge.BlockAt(ctx, client, from)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "getting the first block")
+	}
+	toBlock, err := bridge.BlockAt(ctx, client, to)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "getting the last block")
+	}
+	// The block at the end time is after the range, unless it is the head.
+	if toBlock > fromBlock {
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(toBlock))
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "getting block:%v", toBlock)
+		}
+		if int64(header.Time) >= to.Unix() {
+			toBlock--
+		}
+	}
+	return fromBlock, toBlock, nil
+}
+
+func blockTime(ctx context.Context, client ethereum.Client, number uint64) (time.Time, error) {
+	header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "getting block:%v", number)
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
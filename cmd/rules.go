@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/alert"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+type rulesTestCmd struct {
+	logger log.Logger
+	Rule   string        `long:"rule" required:"true" description:"Name of the rule in the config"`
+	From   string        `long:"from" required:"true" description:"Start of the range, as 2006-01-02 or RFC3339"`
+	To     string        `long:"to" description:"End of the range, as 2006-01-02 or RFC3339. Defaults to now"`
+	Step   time.Duration `long:"step" default:"5m" description:"Time between the evaluations"`
+}
+
+func (self *rulesTestCmd) Execute(args []string) error {
+	from, to, err := parseRange(self.From, self.To)
+	if err != nil {
+		return err
+	}
+	cfg, store, tsdb, err := newStore(self.logger)
+	if err != nil {
+		return err
+	}
+	defer tsdb.Close()
+
+	var rule *alert.RuleConfig
+	for i := range cfg.Alert.Rules {
+		if cfg.Alert.Rules[i].Name == self.Rule {
+			rule = &cfg.Alert.Rules[i]
+		}
+	}
+	if rule == nil {
+		return errors.Errorf("no rule:%v in the config", self.Rule)
+	}
+
+	transitions, err := alert.Backtest(context.Background(), *rule, store, from, to, self.Step)
+	for _, t := range transitions {
+		level.Info(self.logger).Log("msg", "rule state changed", "time", t.Time.UTC(), "state", t.State, "since", t.Since.UTC(), "value", t.Result.Value, "summary", t.Result.Summary)
+	}
+	if err != nil {
+		return err
+	}
+	level.Info(self.logger).Log("msg", "rule tested", "rule", self.Rule, "transitions", len(transitions))
+	return nil
+}
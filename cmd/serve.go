@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	stdlog "log"
+	"os"
+	"syscall"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/alert"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/bsc/bsciotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/bsc/iotexbsc"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/eth/ethiotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/eth/iotexeth"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/poly/iotexpoly"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/poly/polyiotex"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/config"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/health"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/price"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/web"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/oklog/run"
+	"github.com/pkg/errors"
+)
+
+// Components that can be disabled when serving.
+const (
+	componentWeb      = "web"
+	componentPrice    = "price"
+	componentAlert    = "alert"
+	componentEthereum = "ethereum"
+	componentPolygon  = "polygon"
+	componentBsc      = "bsc"
+)
+
+type serveCmd struct {
+	logger  log.Logger
+	Disable []string `long:"disable" choice:"web" choice:"price" choice:"alert" choice:"ethereum" choice:"polygon" choice:"bsc" description:"Don't start the component, can be repeated. The bridges disable both of their trackers"`
+}
+
+func (self *serveCmd) Execute(args []string) error {
+	serve(self.logger, self.Disable)
+	return nil
+}
+
+func isDisabled(disabled []string, component string) bool {
+	for _, d := range disabled {
+		if d == component {
+			return true
+		}
+	}
+	return false
+}
+
+// enabledTrackers are the tx trackers of the bridges that aren't disabled.
+func enabledTrackers(disabled []string) []health.Tracker {
+	components := map[types.Network]string{
+		types.NetEthereum: componentEthereum,
+		types.NetPolygon:  componentPolygon,
+		types.NetBsc:      componentBsc,
+	}
+	trackers := make([]health.Tracker, 0, len(health.Trackers))
+	for _, t := range health.Trackers {
+		// Every bridge is between iotex and the network of its component.
+		network := t.Network
+		if network == types.NetIoTeX {
+			network = t.Peer
+		}
+		if !isDisabled(disabled, components[network]) {
+			trackers = append(trackers, t)
+		}
+	}
+	return trackers
+}
+
+// enabledNetworks are the networks of the trackers of the bridges that aren't disabled.
+func enabledNetworks(disabled []string) []types.Network {
+	networks := make([]types.Network, 0)
+	seen := make(map[types.Network]bool)
+	for _, t := range enabledTrackers(disabled) {
+		for _, network := range []types.Network{t.Network, t.Peer} {
+			if !seen[network] {
+				seen[network] = true
+				networks = append(networks, network)
+			}
+		}
+	}
+	return networks
+}
+
+func serve(logger log.Logger, disabled []string) {
+	cfg, err := config.ParseConfig(logger, options.Config)
+	if err != nil {
+		ExitOnErr(err, "creating config")
+
+	}
+	globalCtx := context.Background()
+
+	// Only the networks of the enabled bridges need nodes.
+	nodes, err := newNodes(globalCtx, logger, cfg.Nodes, enabledNetworks(disabled))
+	if err != nil {
+		ExitOnErr(err, "creating node clients")
+	}
+	ethNode := nodes[types.NetEthereum]
+	iotexNode := nodes[types.NetIoTeX]
+	polygonNode := nodes[types.NetPolygon]
+	bscNode := nodes[types.NetBsc]
+
+	// Influxdb client.
+	tsdb := influxdb2.NewClient(os.Getenv("INFLUXDB_URL"), os.Getenv("INFLUXDB_TOKEN"))
+	// always close client at the end
+	defer tsdb.Close()
+
+	var g run.Group
+	{
+		g.Add(run.SignalHandler(context.Background(), syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM))
+		store, err := bridge.NewSore(globalCtx, logger, cfg.Bridge, tsdb)
+		if err != nil {
+			ExitOnErr(err, "creating bridge store")
+		}
+
+		// Health checks of the storage, the nodes and the trackers.
+		health, err := health.New(logger, cfg.Health, tsdb, store, headReaders(nodes), enabledTrackers(disabled))
+		if err != nil {
+			ExitOnErr(err, "creating health checks")
+		}
+
+		// web api component.
+		if !isDisabled(disabled, componentWeb) {
+			web, err := web.New(logger, globalCtx, tsdb, store, health, cfg.Web)
+			if err != nil {
+				ExitOnErr(err, "creating web controller")
+			}
+			g.Add(func() error {
+				return web.Start()
+			}, func(error) {
+				web.Stop()
+			})
+		}
+
+		// Price tracker component.
+		// The tvl trackers read its latest prices even when it isn't started.
+		price, err := price.New(logger, globalCtx, store, cfg.Price)
+		if err != nil {
+			ExitOnErr(err, "creating price tracker")
+		}
+		// The new transfers are valued with the latest prices too.
+		store.SetPrices(price)
+		if !isDisabled(disabled, componentPrice) {
+			g.Add(func() error {
+				return price.Start()
+			}, func(error) {
+				price.Stop()
+			})
+		}
+
+		// Alerts component, when there is somewhere to send them or rules to evaluate.
+		// The rules without webhooks still log their state changes and export their firing metric.
+		if (len(cfg.Alert.Webhooks) > 0 || len(cfg.Alert.Rules) > 0) && !isDisabled(disabled, componentAlert) {
+			alerter, err := alert.New(logger, globalCtx, store, health, cfg.Alert)
+			if err != nil {
+				ExitOnErr(err, "creating alerts")
+			}
+			g.Add(func() error {
+				return alerter.Start()
+			}, func(error) {
+				alerter.Stop()
+			})
+		}
+
+		// Ethereum bridge
+		if !isDisabled(disabled, componentEthereum) {
+			// Ethereum part.
+			{
+				{
+					// ethereum tx tracker.
+					ethTXTracker, err := ethiotex.NewTransactionTracker(globalCtx, ethNode, logger, cfg.EthIoTeX, store)
+					if err != nil {
+						ExitOnErr(err, "creating ethTXTracker")
+					}
+					g.Add(func() error {
+						level.Info(logger).Log("msg", "ethiotex tx tracker started")
+						return ethTXTracker.Start()
+					}, func(error) {
+						ethTXTracker.Stop()
+						level.Info(logger).Log("msg", "ethiotex tx tracker shutdown complete")
+
+					})
+
+					// ethereum tvl tracker.
+					if true {
+						ethTVLTracker, err := ethiotex.NewTVLTracker(globalCtx, ethNode, logger, cfg.EthIoTeX, store, price)
+						if err != nil {
+							ExitOnErr(err, "creating ethTVLTracker")
+						}
+						g.Add(func() error {
+							level.Info(logger).Log("msg", "ethiotex tvl tracker started")
+							return ethTVLTracker.Start()
+						}, func(error) {
+							ethTVLTracker.Stop()
+							level.Info(logger).Log("msg", "ethiotex tvl tracker shutdown complete")
+
+						})
+					}
+				}
+			}
+			// iotex part.
+			{
+				// ethereum tx tracker.
+				iotexEthTXTracker, err := iotexeth.NewTransactionTracker(globalCtx, iotexNode, logger, cfg.IoTeXEth, store)
+				if err != nil {
+					ExitOnErr(err, "creating iotexEthTXTracker")
+				}
+				g.Add(func() error {
+
+					level.Info(logger).Log("msg", "iotexeth tx tracker started")
+					return iotexEthTXTracker.Start()
+				}, func(error) {
+					iotexEthTXTracker.Stop()
+					level.Info(logger).Log("msg", "iotexeth tx tracker shutdown complete")
+				})
+
+			}
+		}
+
+		// Polygon bridge
+		if !isDisabled(disabled, componentPolygon) {
+			// Polygon part.
+			{
+				{
+					// Polygon tx tracker.
+					polyTXTracker, err := polyiotex.NewTransactionTracker(globalCtx, polygonNode, logger, cfg.PolyIoTeX, store)
+					if err != nil {
+						ExitOnErr(err, "creating polyTXTracker")
+					}
+					g.Add(func() error {
+						level.Info(logger).Log("msg", "polyiotex tx tracker started")
+						return polyTXTracker.Start()
+					}, func(error) {
+						polyTXTracker.Stop()
+						level.Info(logger).Log("msg", "polyiotex tx tracker shutdown complete")
+					})
+
+					// Polygon tvl tracker.
+					if true {
+						polyTVLTracker, err := polyiotex.NewTVLTracker(globalCtx, polygonNode, logger, cfg.PolyIoTeX, store, price)
+						if err != nil {
+							ExitOnErr(err, "creating polyTVLTracker")
+						}
+						g.Add(func() error {
+
+							level.Info(logger).Log("msg", "polyiotex tvl tracker started")
+							return polyTVLTracker.Start()
+						}, func(error) {
+							polyTVLTracker.Stop()
+							level.Info(logger).Log("msg", "polyiotex tvl tracker shutdown complete")
+						})
+					}
+				}
+			}
+			// iotex part.
+			{
+				// ethereum tx tracker.
+				iotexPolyTXTracker, err := iotexpoly.NewTransactionTracker(globalCtx, iotexNode, logger, cfg.IoTeXPoly, store)
+				if err != nil {
+					ExitOnErr(err, "creating iotexPolyTXTracker")
+				}
+				g.Add(func() error {
+					level.Info(logger).Log("msg", "iotexpoly tx tracker started")
+					return iotexPolyTXTracker.Start()
+				}, func(error) {
+					iotexPolyTXTracker.Stop()
+					level.Info(logger).Log("msg", "iotexpoly tx tracker shutdown complete")
+				})
+			}
+		}
+
+		// Bsc bridge
+		if !isDisabled(disabled, componentBsc) {
+			// BSC part.
+			{
+				{
+					// BSC tx tracker.
+					bscTXTracker, err := bsciotex.NewTransactionTracker(globalCtx, bscNode, logger, cfg.BscIoTeX, store)
+					if err != nil {
+						ExitOnErr(err, "creating bscTXTracker")
+					}
+					g.Add(func() error {
+						level.Info(logger).Log("msg", "bsciotex tx tracker started")
+						return bscTXTracker.Start()
+					}, func(error) {
+						bscTXTracker.Stop()
+						level.Info(logger).Log("msg", "bsciotex tx tracker shutdown complete")
+					})
+
+					// Bsc tvl tracker.
+					{
+						bscTVLTracker, err := bsciotex.NewTVLTracker(globalCtx, bscNode, logger, cfg.BscIoTeX, store, price)
+						if err != nil {
+							ExitOnErr(err, "creating bscTVLTracker")
+						}
+						g.Add(func() error {
+
+							level.Info(logger).Log("msg", "bsciotex tvl tracker started")
+							return bscTVLTracker.Start()
+						}, func(error) {
+							bscTVLTracker.Stop()
+							level.Info(logger).Log("msg", "bsciotex tvl tracker shutdown complete")
+						})
+					}
+				}
+			}
+			// iotex part.
+			{
+				// ethereum tx tracker.
+				iotexBscTXTracker, err := iotexbsc.NewTransactionTracker(globalCtx, iotexNode, logger, cfg.IoTeXBsc, store)
+				if err != nil {
+					ExitOnErr(err, "creating iotexBscTXTracker")
+				}
+				g.Add(func() error {
+					level.Info(logger).Log("msg", "iotexbsc tx tracker started")
+					return iotexBscTXTracker.Start()
+				}, func(error) {
+					iotexBscTXTracker.Stop()
+					level.Info(logger).Log("msg", "iotexbsc tx tracker shutdown complete")
+				})
+			}
+		}
+	}
+
+	if err := g.Run(); err != nil {
+		stdlog.Println(fmt.Sprintf("%+v", errors.Wrapf(err, "run group stacktrace")))
+	}
+
+}
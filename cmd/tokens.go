@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+)
+
+type tokensCmd struct {
+	logger  log.Logger
+	Network string `long:"network" description:"Only list the tokens of the given network"`
+	JSON    bool   `long:"json" description:"Print the tokens as json"`
+}
+
+// tokenInfo is a bridged token and its price id from the config,
+// empty when the price id is looked up by the token address.
+type tokenInfo struct {
+	Network types.Network `json:"network"`
+	Symbol  string        `json:"symbol"`
+	Address string        `json:"address"`
+	PriceID string        `json:"priceId,omitempty"`
+}
+
+func (self *tokensCmd) Execute(args []string) error {
+	cfg, store, tsdb, err := newStore(self.logger)
+	if err != nil {
+		return err
+	}
+	defer tsdb.Close()
+
+	tokens, err := store.GetAllTokens()
+	if err != nil {
+		return errors.Wrap(err, "getting tokens")
+	}
+	ids := make(map[string]string)
+	for _, t := range cfg.Price.Tokens {
+		ids[string(t.Network)+":"+strings.ToLower(t.Address)] = t.ID
+	}
+	infos := make([]tokenInfo, 0, len(tokens))
+	for _, t := range tokens {
+		if self.Network != "" && string(t.Network) != self.Network {
+			continue
+		}
+		infos = append(infos, tokenInfo{
+			Network: t.Network,
+			Symbol:  t.Symbol,
+			Address: t.Address,
+			PriceID: ids[string(t.Network)+":"+strings.ToLower(t.Address)],
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Network != infos[j].Network {
+			return infos[i].Network < infos[j].Network
+		}
+		return infos[i].Symbol < infos[j].Symbol
+	})
+
+	if self.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NETWORK\tSYMBOL\tADDRESS\tPRICE ID")
+	for _, t := range infos {
+		priceID := t.PriceID
+		if priceID == "" {
+			priceID = "-"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", t.Network, t.Symbol, t.Address, priceID)
+	}
+	return w.Flush()
+}
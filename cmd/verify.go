@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/health"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+type verifyHealthCmd struct {
+	logger log.Logger
+}
+
+func (self *verifyHealthCmd) Execute(args []string) error {
+	cfg, store, tsdb, err := newStore(self.logger)
+	if err != nil {
+		return err
+	}
+	defer tsdb.Close()
+
+	ctx := context.Background()
+	nodes, err := newNodes(ctx, self.logger, cfg.Nodes, health.Networks)
+	if err != nil {
+		return err
+	}
+	checks, err := health.New(self.logger, cfg.Health, tsdb, store, headReaders(nodes), health.Trackers)
+	if err != nil {
+		return errors.Wrap(err, "creating health checks")
+	}
+	report := checks.Check(ctx)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.Wrap(err, "writing report")
+	}
+	if !report.Ready {
+		return errors.New("service not ready")
+	}
+	return nil
+}
+
+type verifyDepositsCmd struct {
+	logger log.Logger
+	Side   string   `long:"side" required:"true" choice:"ethiotex" choice:"iotexeth" choice:"polyiotex" choice:"iotexpoly" choice:"bsciotex" choice:"iotexbsc" description:"Tracker of the bridge side to verify"`
+	Tokens []string `long:"token" description:"Token address to check even without stored transfers, can be repeated"`
+}
+
+// tokenLister is a tracker that knows the tokens of its bridge.
+type tokenLister interface {
+	Tokens() []common.Address
+}
+
+func (self *verifyDepositsCmd) Execute(args []string) error {
+	cfg, store, tsdb, err := newStore(self.logger)
+	if err != nil {
+		return err
+	}
+	defer tsdb.Close()
+
+	ctx := context.Background()
+	nodes, err := newNodes(ctx, self.logger, cfg.Nodes, []types.Network{sideNetworks[self.Side]})
+	if err != nil {
+		return err
+	}
+	t, err := newSideTracker(ctx, self.logger, self.Side, cfg, nodes, store)
+	if err != nil {
+		return err
+	}
+	// The counters are compared at the last block the tracker recorded.
+	lastChecked, err := store.LastCheckedBlockNo(t.network, t.peer)
+	if err != nil {
+		return errors.Wrap(err, "getting the last checked block")
+	}
+	if lastChecked == nil {
+		return errors.Errorf("the %v tracker didn't record any block yet", self.Side)
+	}
+	tokens := make([]common.Address, 0, len(self.Tokens))
+	for _, token := range self.Tokens {
+		if !common.IsHexAddress(token) {
+			return errors.Errorf("invalid token address:%v", token)
+		}
+		tokens = append(tokens, common.HexToAddress(token))
+	}
+	if lister, ok := t.tracker.(tokenLister); ok {
+		tokens = append(tokens, lister.Tokens()...)
+	}
+
+	report, err := store.VerifyDeposits(ctx, nodes[t.network], t.cashier, t.bridge, t.side, t.startBlock, lastChecked.Uint64(), tokens)
+	if err != nil {
+		return errors.Wrap(err, "verifying deposits")
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.Wrap(err, "writing report")
+	}
+	for _, token := range report.Tokens {
+		for _, gap := range token.Gaps {
+			level.Warn(self.logger).Log("msg", "missing deposits", "token", token.Token, "symbol", token.Symbol,
+				"fromId", gap.FromID, "toId", gap.ToID, "fromBlock", gap.FromBlock, "toBlock", gap.ToBlock)
+		}
+	}
+	if !report.Complete {
+		return errors.New("stored deposits are incomplete")
+	}
+	return nil
+}
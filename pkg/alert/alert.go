@@ -0,0 +1,152 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package alert notifies about unusual bridge activity through webhooks.
+package alert
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/health"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/logging"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const ComponentName = "alert"
+
+type Config struct {
+	LogLevel string
+	// Transfers over any of the thresholds of their bridge fire a large transfer alert.
+	Transfers []Threshold
+	// Rules evaluated against the store every RulesInterval.
+	Rules         []RuleConfig
+	RulesInterval format.Duration
+	Webhooks      []WebhookConfig
+	Delivery      DeliveryConfig
+}
+
+// Alert is a notification sent to all notifiers.
+type Alert struct {
+	// Key identifies the alert, the same key is delivered only once to every notifier.
+	Key     string            `json:"key"`
+	Name    string            `json:"name"`
+	Summary string            `json:"summary"`
+	Time    time.Time         `json:"time"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// Notifier delivers the alerts to an external service.
+type Notifier interface {
+	// Name of the notifier, recorded in the delivery log.
+	Name() string
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Alerter watches the store for large transfers, evaluates the rules
+// and sends the alerts to the notifiers.
+type Alerter struct {
+	logger     log.Logger
+	cfg        Config
+	ctx        context.Context
+	stop       context.CancelFunc
+	store      *bridge.Store
+	rules      *Rules
+	dispatcher *Dispatcher
+}
+
+func New(logger log.Logger, ctx context.Context, store *bridge.Store, health *health.Health, cfg Config) (*Alerter, error) {
+	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	logger = log.With(logger, "component", ComponentName)
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validating config")
+	}
+	rules, err := NewRules(cfg.Rules, store, health)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating rules")
+	}
+	client := &http.Client{Timeout: cfg.Delivery.Timeout.Duration}
+	notifiers, err := NewWebhooks(cfg.Webhooks, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating webhooks")
+	}
+	ctx, stop := context.WithCancel(ctx)
+	return &Alerter{
+		logger:     logger,
+		cfg:        cfg,
+		ctx:        ctx,
+		stop:       stop,
+		store:      store,
+		rules:      rules,
+		dispatcher: NewDispatcher(logger, cfg.Delivery, store, notifiers),
+	}, nil
+}
+
+// Validate checks the thresholds, the rules and the webhooks.
+func (self Config) Validate() error {
+	for _, t := range self.Transfers {
+		if err := t.validate(); err != nil {
+			return errors.Wrap(err, "validating transfer threshold")
+		}
+	}
+	notifiers, err := NewWebhooks(self.Webhooks, nil)
+	if err != nil {
+		return errors.Wrap(err, "validating webhooks")
+	}
+	names := make([]string, 0, len(notifiers))
+	for _, n := range notifiers {
+		names = append(names, n.Name())
+	}
+	rules := make([]string, 0, len(self.Rules))
+	for _, rule := range self.Rules {
+		if err := rule.validate(); err != nil {
+			return errors.Wrap(err, "validating rule")
+		}
+		if contains(rules, rule.Name) {
+			return errors.Errorf("duplicate rule name:%v", rule.Name)
+		}
+		rules = append(rules, rule.Name)
+		for _, name := range rule.Notifiers {
+			if !contains(names, name) {
+				return errors.Errorf("rule:%v unknown notifier:%v", rule.Name, name)
+			}
+		}
+	}
+	if len(self.Rules) > 0 && self.RulesInterval.Duration <= 0 {
+		return errors.New("rules interval needs to be positive")
+	}
+	return nil
+}
+
+func (self *Alerter) Start() error {
+	level.Info(self.logger).Log("msg", "starting alerts", "webhooks", len(self.cfg.Webhooks), "thresholds", len(self.cfg.Transfers), "rules", len(self.cfg.Rules))
+	go self.dispatcher.Run(self.ctx)
+	go self.evaluateRules()
+	self.watchTransfers()
+	return errors.New("context canceled")
+}
+
+func (self *Alerter) Stop() {
+	self.stop()
+}
+
+// DeliveryConfig of the alerts delivery.
+type DeliveryConfig struct {
+	// Retries of a failed delivery, the delay doubles after every retry.
+	Retries    int
+	RetryDelay format.Duration
+	// Timeout of every delivery attempt.
+	Timeout format.Duration
+	// DedupWindow is how long a delivered alert isn't sent again.
+	DedupWindow format.Duration
+	// QueueSize is the max number of alerts waiting for delivery to every notifier.
+	QueueSize int
+}
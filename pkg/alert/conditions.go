@@ -0,0 +1,179 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/health"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// tvlPoints is the number of tvl points within the window a drop is measured on.
+const tvlPoints = 12
+
+// tvlDrop compares the last tvl of the network with its peak within the window.
+// The usd values are summed over all symbols, or the token amounts when the rule has a symbol.
+// Every symbol keeps its last value in the intervals without a point, or without a usd value when its price was stale,
+// so a missing point doesn't look like a drop. Symbols without any usd value are skipped.
+func tvlDrop(cfg RuleConfig, store *bridge.Store) condition {
+	return func(ctx context.Context, at time.Time) (Result, error) {
+		interval := cfg.Window.Duration / tvlPoints
+		if interval < time.Minute {
+			interval = time.Minute
+		}
+		points, err := store.TVL(ctx, types.Query{
+			From:     at.Add(-cfg.Window.Duration),
+			To:       at,
+			Interval: interval,
+			Network:  cfg.Network,
+			Symbol:   cfg.Symbol,
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		name := string(cfg.Network)
+		if cfg.Symbol != "" {
+			name += " " + cfg.Symbol
+		}
+		totals := tvlTotals(points, cfg.Symbol == "")
+		if len(totals) == 0 {
+			return Result{Summary: fmt.Sprintf("no %v tvl within %v", name, cfg.Window.Duration)}, nil
+		}
+		var peak float64
+		for _, total := range totals {
+			if total > peak {
+				peak = total
+			}
+		}
+		var drop float64
+		if last := totals[len(totals)-1]; peak > 0 {
+			drop = (peak - last) / peak * 100
+		}
+		return Result{
+			Active:  drop >= cfg.Threshold,
+			Value:   drop,
+			Summary: fmt.Sprintf("%v tvl dropped %.1f%% within %v", name, drop, cfg.Window.Duration),
+		}, nil
+	}
+}
+
+// tvlTotals sums the tvl of all symbols at every time with a point, in time order.
+// Every symbol carries its last value forward over the times without its own point.
+func tvlTotals(points []types.TVLPoint, usd bool) []float64 {
+	// Map: symbol -> time -> value.
+	series := make(map[string]map[time.Time]float64)
+	seen := make(map[time.Time]bool)
+	for _, p := range points {
+		value := p.Value
+		if usd {
+			if !p.HasUSD {
+				continue
+			}
+			value = p.ValueUSD
+		}
+		symbol := string(p.Network) + ":" + p.Symbol
+		if _, ok := series[symbol]; !ok {
+			series[symbol] = make(map[time.Time]float64)
+		}
+		series[symbol][p.Time] = value
+		seen[p.Time] = true
+	}
+	times := make([]time.Time, 0, len(seen))
+	for t := range seen {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	totals := make([]float64, len(times))
+	for _, values := range series {
+		var last float64
+		for i, t := range times {
+			if v, ok := values[t]; ok {
+				last = v
+			}
+			totals[i] += last
+		}
+	}
+	return totals
+}
+
+// noTransfers counts the transfers of the bridge within the window.
+func noTransfers(cfg RuleConfig, store *bridge.Store) condition {
+	return func(ctx context.Context, at time.Time) (Result, error) {
+		points, err := store.Volume(ctx, types.Query{
+			From:     at.Add(-cfg.Window.Duration),
+			To:       at,
+			Interval: cfg.Window.Duration,
+			Bridge:   cfg.Bridge,
+			Side:     cfg.Side,
+			Symbol:   cfg.Symbol,
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		var count int64
+		for _, p := range points {
+			count += p.Count
+		}
+		return Result{
+			Active:  float64(count) <= cfg.Threshold,
+			Value:   float64(count),
+			Summary: fmt.Sprintf("%v transfers on the %v bridge within %v", count, cfg.Bridge, cfg.Window.Duration),
+		}, nil
+	}
+}
+
+// feeSpike compares the fees within the window with the average fees
+// of the same window length over the baseline before it.
+func feeSpike(cfg RuleConfig, store *bridge.Store) condition {
+	return func(ctx context.Context, at time.Time) (Result, error) {
+		start := at.Add(-cfg.Window.Duration)
+		q := types.Query{From: start, To: at, Bridge: cfg.Bridge, Side: cfg.Side, Symbol: cfg.Symbol}
+		fees, err := store.Fees(ctx, q)
+		if err != nil {
+			return Result{}, err
+		}
+		q.From, q.To = start.Add(-cfg.Baseline.Duration), start
+		baseline, err := store.Fees(ctx, q)
+		if err != nil {
+			return Result{}, err
+		}
+		expected := baseline * float64(cfg.Window.Duration) / float64(cfg.Baseline.Duration)
+		var ratio float64
+		if expected > 0 {
+			ratio = fees / expected
+		}
+		return Result{
+			Active:  expected > 0 && ratio >= cfg.Threshold,
+			Value:   ratio,
+			Summary: fmt.Sprintf("%v bridge %v side fees within %v are %.1f times the baseline", cfg.Bridge, cfg.Side, cfg.Window.Duration, ratio),
+		}, nil
+	}
+}
+
+// trackerLag reads the lag of the tracker from the health checks.
+func trackerLag(cfg RuleConfig, h *health.Health) condition {
+	return func(ctx context.Context, at time.Time) (Result, error) {
+		for _, t := range h.Check(ctx).Trackers {
+			if t.Network != cfg.Network || t.Peer != cfg.Peer {
+				continue
+			}
+			if t.Head == 0 || t.LastChecked == 0 {
+				return Result{}, errors.Errorf("tracker lag unknown:%v", t.Error)
+			}
+			return Result{
+				Active:  float64(t.Lag) >= cfg.Threshold,
+				Value:   float64(t.Lag),
+				Summary: fmt.Sprintf("%v tracker of the %v bridge is %v blocks behind the head", cfg.Network, cfg.Peer, t.Lag),
+			}, nil
+		}
+		return Result{}, errors.Errorf("no tracker of network:%v peer:%v", cfg.Network, cfg.Peer)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package alert
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+)
+
+func TestTVLTotals(t *testing.T) {
+	t0 := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	t1, t2 := t0.Add(time.Hour), t0.Add(2*time.Hour)
+	point := func(at time.Time, symbol string, value, usd float64, hasUSD bool) types.TVLPoint {
+		return types.TVLPoint{Time: at, Network: types.NetEthereum, Symbol: symbol, Value: value, ValueUSD: usd, HasUSD: hasUSD}
+	}
+	cases := []struct {
+		name     string
+		points   []types.TVLPoint
+		usd      bool
+		expected []float64
+	}{
+		{
+			name:     "no points",
+			usd:      true,
+			expected: []float64{},
+		},
+		{
+			name: "summed over the symbols",
+			points: []types.TVLPoint{
+				point(t0, "WETH", 1, 100, true), point(t0, "USDT", 50, 50, true),
+				point(t1, "WETH", 1, 120, true), point(t1, "USDT", 40, 40, true),
+			},
+			usd:      true,
+			expected: []float64{150, 160},
+		},
+		{
+			name: "missing point carries the last value forward",
+			points: []types.TVLPoint{
+				point(t0, "WETH", 1, 100, true), point(t0, "USDT", 50, 50, true),
+				point(t1, "USDT", 50, 50, true),
+				point(t2, "WETH", 1, 90, true), point(t2, "USDT", 50, 50, true),
+			},
+			usd:      true,
+			expected: []float64{150, 150, 140},
+		},
+		{
+			name: "stale price carries the last usd value forward",
+			points: []types.TVLPoint{
+				point(t0, "WETH", 1, 100, true), point(t0, "USDT", 50, 50, true),
+				point(t1, "WETH", 1, 0, false), point(t1, "USDT", 50, 50, true),
+				point(t2, "WETH", 1, 110, true), point(t2, "USDT", 50, 50, true),
+			},
+			usd:      true,
+			expected: []float64{150, 150, 160},
+		},
+		{
+			name: "symbol without any usd value is skipped",
+			points: []types.TVLPoint{
+				point(t0, "WETH", 1, 100, true), point(t0, "NEW", 500, 0, false),
+				point(t1, "WETH", 1, 100, true), point(t1, "NEW", 500, 0, false),
+			},
+			usd:      true,
+			expected: []float64{100, 100},
+		},
+		{
+			name: "symbol appearing later counts from its first point",
+			points: []types.TVLPoint{
+				point(t0, "WETH", 1, 100, true),
+				point(t1, "WETH", 1, 100, true), point(t1, "USDT", 20, 20, true),
+			},
+			usd:      true,
+			expected: []float64{100, 120},
+		},
+		{
+			name: "token amounts",
+			points: []types.TVLPoint{
+				point(t0, "WETH", 2, 0, false),
+				point(t2, "WETH", 1, 0, false),
+				point(t1, "WETH", 3, 0, false),
+			},
+			expected: []float64{2, 3, 1},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			totals := tvlTotals(c.points, c.usd)
+			if !reflect.DeepEqual(totals, c.expected) {
+				t.Fatalf("unexpected totals:%v, expected:%v", totals, c.expected)
+			}
+		})
+	}
+}
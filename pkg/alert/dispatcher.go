@@ -0,0 +1,182 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package alert
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/metrics"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// permanentError is a delivery failure that retries can't fix, like a rejected payload.
+type permanentError struct {
+	error
+}
+
+// Dispatcher delivers the alerts to all notifiers with retries
+// and skips the alerts that were already delivered.
+// Every notifier has its own queue, so a slow or failing one doesn't hold back the others.
+// Every delivery is recorded in the delivery log of the store.
+type Dispatcher struct {
+	logger    log.Logger
+	cfg       DeliveryConfig
+	store     *bridge.Store
+	notifiers []Notifier
+	// Map: notifier name -> its queued alerts.
+	queues map[string]chan Alert
+
+	mtx sync.Mutex
+	// Map: notifier/alert key -> delivery time.
+	delivered map[string]time.Time
+}
+
+func NewDispatcher(logger log.Logger, cfg DeliveryConfig, store *bridge.Store, notifiers []Notifier) *Dispatcher {
+	if cfg.QueueSize < 1 {
+		cfg.QueueSize = 1
+	}
+	queues := make(map[string]chan Alert, len(notifiers))
+	for _, n := range notifiers {
+		queues[n.Name()] = make(chan Alert, cfg.QueueSize)
+	}
+	return &Dispatcher{
+		logger:    logger,
+		cfg:       cfg,
+		store:     store,
+		notifiers: notifiers,
+		queues:    queues,
+		delivered: make(map[string]time.Time),
+	}
+}
+
+// Send queues the alert for delivery to the given notifiers, or all notifiers, without blocking.
+func (self *Dispatcher) Send(alert Alert, notifiers ...string) {
+	for _, n := range self.notifiers {
+		if len(notifiers) > 0 && !contains(notifiers, n.Name()) {
+			continue
+		}
+		select {
+		case self.queues[n.Name()] <- alert:
+		default:
+			level.Error(self.logger).Log("msg", "alerts queue is full, dropping alert", "notifier", n.Name(), "alert", alert.Name, "key", alert.Key)
+		}
+	}
+}
+
+// Run delivers the queued alerts of every notifier in its own goroutine until the context is canceled.
+func (self *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, n := range self.notifiers {
+		wg.Add(1)
+		go func(n Notifier) {
+			defer wg.Done()
+			queue := self.queues[n.Name()]
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case alert := <-queue:
+					self.deliver(ctx, n, alert)
+				}
+			}
+		}(n)
+	}
+	wg.Wait()
+}
+
+func (self *Dispatcher) deliver(ctx context.Context, n Notifier, alert Alert) {
+	logger := log.With(self.logger, "notifier", n.Name(), "alert", alert.Name, "key", alert.Key)
+	if self.isDelivered(ctx, n.Name(), alert.Key) {
+		level.Debug(logger).Log("msg", "skipping already delivered alert")
+		return
+	}
+
+	var (
+		err      error
+		attempts int
+		delay    = self.cfg.RetryDelay.Duration
+	)
+	for attempts <= self.cfg.Retries {
+		if attempts > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		attempts++
+		err = n.Notify(ctx, alert)
+		if err == nil {
+			break
+		}
+		level.Warn(logger).Log("msg", "delivering alert", "attempt", attempts, "err", err)
+		if _, ok := errors.Cause(err).(permanentError); ok {
+			break
+		}
+	}
+	metrics.AlertDeliveries.WithLabelValues(n.Name(), metrics.Outcome(err)).Inc()
+
+	d := types.AlertDelivery{
+		Time:     time.Now(),
+		Key:      alert.Key,
+		Alert:    alert.Name,
+		Notifier: n.Name(),
+		Status:   types.DeliveryDelivered,
+		Attempts: attempts,
+	}
+	if err != nil {
+		level.Error(logger).Log("msg", "alert delivery failed", "attempts", attempts, "err", err)
+		d.Status, d.Error = types.DeliveryFailed, err.Error()
+	} else {
+		self.markDelivered(n.Name(), alert.Key, d.Time)
+	}
+	if err := self.store.RecordAlertDelivery(d); err != nil {
+		level.Error(logger).Log("msg", "recording alert delivery", "err", err)
+	}
+}
+
+// isDelivered checks the recent deliveries and then the delivery log,
+// so alerts aren't repeated after a restart or when transfers are recorded again.
+func (self *Dispatcher) isDelivered(ctx context.Context, notifier, key string) bool {
+	self.mtx.Lock()
+	at, ok := self.delivered[notifier+"/"+key]
+	self.mtx.Unlock()
+	if ok && time.Since(at) < self.cfg.DedupWindow.Duration {
+		return true
+	}
+	delivered, err := self.store.AlertDelivered(ctx, key, notifier, self.cfg.DedupWindow.Duration)
+	if err != nil {
+		// Better a duplicate than a missed alert.
+		level.Error(self.logger).Log("msg", "checking the delivery log", "err", err)
+		return false
+	}
+	return delivered
+}
+
+func (self *Dispatcher) markDelivered(notifier, key string, at time.Time) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	for k, t := range self.delivered {
+		if time.Since(t) >= self.cfg.DedupWindow.Duration {
+			delete(self.delivered, k)
+		}
+	}
+	self.delivered[notifier+"/"+key] = at
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,308 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package alert
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/health"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/metrics"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	// RuleTVLDrop fires when the tvl of a network drops by more than Threshold percent within the window.
+	RuleTVLDrop = "tvl_drop"
+	// RuleNoTransfers fires when a bridge has at most Threshold transfers within the window.
+	RuleNoTransfers = "no_transfers"
+	// RuleFeeSpike fires when the fees of a bridge side within the window
+	// are more than Threshold times the average of the same window over the baseline before it.
+	RuleFeeSpike = "fee_spike"
+	// RuleTrackerLag fires when a tracker is more than Threshold blocks behind the head of its network.
+	RuleTrackerLag = "tracker_lag"
+)
+
+type RuleState string
+
+const (
+	StateInactive RuleState = "inactive"
+	// StatePending is a rule with an active condition that didn't hold long enough to fire.
+	StatePending  RuleState = "pending"
+	StateFiring   RuleState = "firing"
+	StateResolved RuleState = "resolved"
+)
+
+type RuleConfig struct {
+	Name string
+	// Type of the rule: tvl_drop, no_transfers, fee_spike or tracker_lag.
+	Type    string
+	Bridge  types.Bridge
+	Side    types.BridgeSide
+	Network types.Network
+	// Peer is the network of the tracker bridge for the tracker_lag rule.
+	Peer   types.Network
+	Symbol string
+	// Threshold is the tvl drop percent, the max transfers count,
+	// the fees ratio to the baseline or the tracker lag in blocks.
+	Threshold float64
+	// Window is the time range the rule looks at before every evaluation.
+	Window format.Duration
+	// Baseline is the time range before the window that the fees are compared to.
+	Baseline format.Duration
+	// For is how long the condition needs to hold before the rule fires.
+	For format.Duration
+	// Notifiers names, empty sends the alerts to all notifiers.
+	Notifiers []string
+}
+
+func (self RuleConfig) validate() error {
+	if self.Name == "" {
+		return errors.New("rule needs a name")
+	}
+	switch self.Type {
+	case RuleTVLDrop:
+		if self.Network == "" || self.Threshold <= 0 || self.Threshold > 100 {
+			return errors.Errorf("rule:%v needs a network and a threshold percent", self.Name)
+		}
+	case RuleNoTransfers:
+		if self.Bridge == "" || self.Threshold < 0 {
+			return errors.Errorf("rule:%v needs a bridge", self.Name)
+		}
+	case RuleFeeSpike:
+		if self.Bridge == "" || self.Side == "" || self.Threshold <= 0 || self.Baseline.Duration <= 0 {
+			return errors.Errorf("rule:%v needs a bridge, a side, a threshold ratio and a baseline", self.Name)
+		}
+	case RuleTrackerLag:
+		if self.Network == "" || self.Peer == "" || self.Threshold <= 0 {
+			return errors.Errorf("rule:%v needs a network, a peer and a threshold of blocks", self.Name)
+		}
+		return nil
+	default:
+		return errors.Errorf("rule:%v unknown type:%v", self.Name, self.Type)
+	}
+	if self.Window.Duration <= 0 {
+		return errors.Errorf("rule:%v needs a window", self.Name)
+	}
+	return nil
+}
+
+// Result of a rule evaluation.
+type Result struct {
+	Active  bool
+	Value   float64
+	Summary string
+}
+
+// Transition is a state change of a rule.
+type Transition struct {
+	Rule  string
+	State RuleState
+	Time  time.Time
+	// Since is when the condition became active.
+	Since  time.Time
+	Result Result
+}
+
+type condition func(ctx context.Context, at time.Time) (Result, error)
+
+// Rule evaluates a condition and keeps its state between evaluations.
+type Rule struct {
+	cfg   RuleConfig
+	cond  condition
+	state RuleState
+	since time.Time
+}
+
+func newRule(cfg RuleConfig, store *bridge.Store, health *health.Health) (*Rule, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	rule := &Rule{cfg: cfg, state: StateInactive}
+	switch cfg.Type {
+	case RuleTVLDrop:
+		rule.cond = tvlDrop(cfg, store)
+	case RuleNoTransfers:
+		rule.cond = noTransfers(cfg, store)
+	case RuleFeeSpike:
+		rule.cond = feeSpike(cfg, store)
+	case RuleTrackerLag:
+		if health == nil {
+			return nil, errors.Errorf("rule:%v needs the health checks", cfg.Name)
+		}
+		rule.cond = trackerLag(cfg, health)
+	}
+	return rule, nil
+}
+
+// step moves the rule to its next state after an evaluation at the given time.
+func (self *Rule) step(res Result, at time.Time) (Transition, bool) {
+	next := self.state
+	switch {
+	case res.Active && (self.state == StateInactive || self.state == StateResolved):
+		self.since = at
+		next = StatePending
+		if self.cfg.For.Duration <= 0 {
+			next = StateFiring
+		}
+	case res.Active && self.state == StatePending:
+		if at.Sub(self.since) >= self.cfg.For.Duration {
+			next = StateFiring
+		}
+	case !res.Active && self.state == StatePending:
+		next = StateInactive
+	case !res.Active && self.state == StateFiring:
+		next = StateResolved
+	}
+	if next == self.state {
+		return Transition{}, false
+	}
+	self.state = next
+	return Transition{Rule: self.cfg.Name, State: next, Time: at, Since: self.since, Result: res}, true
+}
+
+// Rules evaluates the configured rules against the store.
+type Rules struct {
+	rules []*Rule
+}
+
+func NewRules(cfgs []RuleConfig, store *bridge.Store, health *health.Health) (*Rules, error) {
+	rules := make([]*Rule, 0, len(cfgs))
+	names := make(map[string]bool)
+	for _, cfg := range cfgs {
+		if names[cfg.Name] {
+			return nil, errors.Errorf("duplicate rule name:%v", cfg.Name)
+		}
+		names[cfg.Name] = true
+		rule, err := newRule(cfg, store, health)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return &Rules{rules: rules}, nil
+}
+
+// evaluate runs all rules at the given time and returns their state changes.
+func (self *Rules) evaluate(ctx context.Context, at time.Time) ([]Transition, []error) {
+	var (
+		transitions []Transition
+		errs        []error
+	)
+	for _, rule := range self.rules {
+		res, err := rule.cond(ctx, at)
+		if err != nil {
+			// The state is kept so a failing query doesn't resolve the alert.
+			errs = append(errs, errors.Wrapf(err, "evaluating rule:%v", rule.cfg.Name))
+			continue
+		}
+		if t, ok := rule.step(res, at); ok {
+			transitions = append(transitions, t)
+		}
+		firing := 0.0
+		if rule.state == StateFiring {
+			firing = 1
+		}
+		metrics.RuleFiring.WithLabelValues(rule.cfg.Name).Set(firing)
+	}
+	return transitions, errs
+}
+
+// Backtest evaluates the rule over the historical data of the store every step
+// and returns all state changes, without notifying.
+func Backtest(ctx context.Context, cfg RuleConfig, store *bridge.Store, from, to time.Time, step time.Duration) ([]Transition, error) {
+	if cfg.Type == RuleTrackerLag {
+		return nil, errors.New("the head blocks history isn't stored so tracker lag rules can't be tested")
+	}
+	if step <= 0 {
+		return nil, errors.New("step needs to be positive")
+	}
+	rule, err := newRule(cfg, store, nil)
+	if err != nil {
+		return nil, err
+	}
+	transitions := make([]Transition, 0)
+	for at := from; !at.After(to); at = at.Add(step) {
+		res, err := rule.cond(ctx, at)
+		if err != nil {
+			return transitions, errors.Wrapf(err, "evaluating at:%v", at)
+		}
+		if t, ok := rule.step(res, at); ok {
+			transitions = append(transitions, t)
+		}
+	}
+	return transitions, nil
+}
+
+// evaluateRules runs the rules every interval and sends the firing and resolved alerts.
+func (self *Alerter) evaluateRules() {
+	if len(self.rules.rules) == 0 {
+		return
+	}
+	ticker := time.NewTicker(self.cfg.RulesInterval.Duration)
+	defer ticker.Stop()
+	for {
+		transitions, errs := self.rules.evaluate(self.ctx, time.Now())
+		for _, err := range errs {
+			level.Error(self.logger).Log("msg", "evaluating rules", "err", err)
+		}
+		for _, t := range transitions {
+			level.Info(self.logger).Log("msg", "rule state changed", "rule", t.Rule, "state", t.State, "value", t.Result.Value, "summary", t.Result.Summary)
+			if t.State != StateFiring && t.State != StateResolved {
+				continue
+			}
+			rule := self.rules.rule(t.Rule)
+			self.dispatcher.Send(ruleAlert(rule.cfg, t), rule.cfg.Notifiers...)
+		}
+		select {
+		case <-self.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *Rules) rule(name string) *Rule {
+	for _, rule := range self.rules {
+		if rule.cfg.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+func ruleAlert(cfg RuleConfig, t Transition) Alert {
+	labels := map[string]string{
+		"rule":  cfg.Name,
+		"type":  cfg.Type,
+		"state": string(t.State),
+		"value": strconv.FormatFloat(t.Result.Value, 'f', -1, 64),
+		"since": t.Since.UTC().Format(time.RFC3339),
+	}
+	for k, v := range map[string]string{
+		"bridge":  string(cfg.Bridge),
+		"side":    string(cfg.Side),
+		"network": string(cfg.Network),
+		"peer":    string(cfg.Peer),
+		"symbol":  cfg.Symbol,
+	} {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+	return Alert{
+		// The firing and the resolved alert of the same activation are delivered once each.
+		Key:     strings.Join([]string{"rule", cfg.Name, strconv.FormatInt(t.Since.Unix(), 10), string(t.State)}, ":"),
+		Name:    cfg.Name,
+		Summary: "[" + strings.ToUpper(string(t.State)) + "] " + t.Result.Summary,
+		Time:    t.Time.UTC(),
+		Labels:  labels,
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+)
+
+func TestRuleStep(t *testing.T) {
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	type step struct {
+		active bool
+		after  time.Duration
+		state  RuleState
+		// changed is whether the evaluation returns a transition.
+		changed bool
+	}
+	cases := []struct {
+		name  string
+		For   time.Duration
+		steps []step
+	}{
+		{
+			name: "fires right away without a for",
+			steps: []step{
+				{active: false, after: 0, state: StateInactive},
+				{active: true, after: time.Minute, state: StateFiring, changed: true},
+				{active: true, after: 2 * time.Minute, state: StateFiring},
+				{active: false, after: 3 * time.Minute, state: StateResolved, changed: true},
+				{active: false, after: 4 * time.Minute, state: StateResolved},
+			},
+		},
+		{
+			name: "pending until the condition holds for long enough",
+			For:  5 * time.Minute,
+			steps: []step{
+				{active: true, after: 0, state: StatePending, changed: true},
+				{active: true, after: 4 * time.Minute, state: StatePending},
+				{active: true, after: 5 * time.Minute, state: StateFiring, changed: true},
+				{active: false, after: 6 * time.Minute, state: StateResolved, changed: true},
+			},
+		},
+		{
+			name: "pending goes back to inactive",
+			For:  5 * time.Minute,
+			steps: []step{
+				{active: true, after: 0, state: StatePending, changed: true},
+				{active: false, after: time.Minute, state: StateInactive, changed: true},
+				{active: true, after: 2 * time.Minute, state: StatePending, changed: true},
+				{active: true, after: 6 * time.Minute, state: StatePending},
+				{active: true, after: 7 * time.Minute, state: StateFiring, changed: true},
+			},
+		},
+		{
+			name: "fires again after resolving",
+			steps: []step{
+				{active: true, after: 0, state: StateFiring, changed: true},
+				{active: false, after: time.Minute, state: StateResolved, changed: true},
+				{active: true, after: 2 * time.Minute, state: StateFiring, changed: true},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule := &Rule{cfg: RuleConfig{Name: "rule", For: format.Duration{Duration: c.For}}, state: StateInactive}
+			for i, s := range c.steps {
+				at := start.Add(s.after)
+				transition, changed := rule.step(Result{Active: s.active}, at)
+				if changed != s.changed {
+					t.Fatalf("step:%v unexpected changed:%v", i, changed)
+				}
+				if rule.state != s.state {
+					t.Fatalf("step:%v unexpected state:%v, expected:%v", i, rule.state, s.state)
+				}
+				if changed && (transition.State != s.state || !transition.Time.Equal(at) || transition.Since.After(at)) {
+					t.Fatalf("step:%v unexpected transition:%+v", i, transition)
+				}
+			}
+		})
+	}
+}
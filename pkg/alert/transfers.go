@@ -0,0 +1,125 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package alert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// AlertLargeTransfer is the name of the alerts of the transfers over a threshold.
+const AlertLargeTransfer = "large_transfer"
+
+// Threshold of the large transfers.
+type Threshold struct {
+	// Bridge of the transfers, empty matches all bridges.
+	Bridge types.Bridge
+	// Symbol of the transfers, empty matches all symbols.
+	Symbol string
+	// USD is the min usd value of a large transfer, zero disables it.
+	USD float64
+	// Amount is the min token amount of a large transfer, zero disables it.
+	Amount float64
+}
+
+func (self Threshold) validate() error {
+	if self.USD <= 0 && self.Amount <= 0 {
+		return errors.Errorf("threshold needs an usd value or an amount:%+v", self)
+	}
+	if self.Amount > 0 && self.Symbol == "" {
+		return errors.Errorf("amount threshold needs a symbol:%+v", self)
+	}
+	return nil
+}
+
+func (self Threshold) match(tx types.Transaction) bool {
+	if self.Bridge != "" && self.Bridge != tx.Bridge {
+		return false
+	}
+	if self.Symbol != "" && !strings.EqualFold(bridge.CanonicalSymbolName(self.Symbol), bridge.CanonicalSymbolName(tx.Symbol)) {
+		return false
+	}
+	return (self.USD > 0 && tx.AmountUSD >= self.USD) || (self.Amount > 0 && tx.Amount >= self.Amount)
+}
+
+// watchTransfers checks every new transfer against the thresholds until the alerter is stopped.
+func (self *Alerter) watchTransfers() {
+	if len(self.cfg.Transfers) == 0 {
+		<-self.ctx.Done()
+		return
+	}
+	hub := self.store.Events()
+	var lastID uint64
+	for {
+		backlog, events, complete, cancel := hub.Subscribe(lastID)
+		if !complete {
+			level.Warn(self.logger).Log("msg", "some transfers were missed by the alerts", "lastID", lastID)
+		}
+		for _, e := range backlog {
+			lastID = e.ID
+			self.checkTransfer(e)
+		}
+	loop:
+		for {
+			select {
+			case <-self.ctx.Done():
+				cancel()
+				return
+			case e, ok := <-events:
+				if !ok {
+					// Fell behind so resubscribe from the last checked event.
+					break loop
+				}
+				lastID = e.ID
+				self.checkTransfer(e)
+			}
+		}
+		cancel()
+	}
+}
+
+func (self *Alerter) checkTransfer(e bridge.Event) {
+	if e.Type != bridge.EventTransfer {
+		return
+	}
+	for _, t := range self.cfg.Transfers {
+		if t.match(*e.Transfer) {
+			self.dispatcher.Send(transferAlert(*e.Transfer))
+			return
+		}
+	}
+}
+
+func transferAlert(tx types.Transaction) Alert {
+	summary := fmt.Sprintf("Large transfer of %v %v", strconv.FormatFloat(tx.Amount, 'f', -1, 64), tx.Symbol)
+	if tx.AmountUSD != 0 {
+		summary += fmt.Sprintf(" ($%.0f)", tx.AmountUSD)
+	}
+	summary += fmt.Sprintf(" on the %v bridge", tx.Bridge)
+	return Alert{
+		Key:     strings.Join([]string{string(tx.Bridge), string(tx.BridgeSide), tx.Hash, tx.DepositID}, ":"),
+		Name:    AlertLargeTransfer,
+		Summary: summary,
+		Time:    time.Unix(int64(tx.Timestamp), 0).UTC(),
+		Labels: map[string]string{
+			"bridge":     string(tx.Bridge),
+			"side":       string(tx.BridgeSide),
+			"symbol":     tx.Symbol,
+			"token":      tx.Token,
+			"amount":     strconv.FormatFloat(tx.Amount, 'f', -1, 64),
+			"amount_usd": strconv.FormatFloat(tx.AmountUSD, 'f', 2, 64),
+			"from":       tx.From,
+			"to":         tx.To,
+			"hash":       tx.Hash,
+			"deposit_id": tx.DepositID,
+		},
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// FormatJSON posts the alert as it is.
+	FormatJSON = "json"
+	// FormatSlack posts a slack incoming webhook message.
+	FormatSlack = "slack"
+	// FormatDiscord posts a discord webhook message.
+	FormatDiscord = "discord"
+)
+
+// discordMaxContent is the max message length accepted by discord.
+const discordMaxContent = 2000
+
+type WebhookConfig struct {
+	Name string
+	URL  string
+	// Format of the payload: json, slack or discord.
+	Format string
+}
+
+// Webhook posts the alerts to an url.
+type Webhook struct {
+	cfg    WebhookConfig
+	client *http.Client
+}
+
+// NewWebhooks creates the webhooks in the same order as in the config.
+// All webhooks share the given client.
+func NewWebhooks(cfgs []WebhookConfig, client *http.Client) ([]Notifier, error) {
+	notifiers := make([]Notifier, 0, len(cfgs))
+	names := make(map[string]bool)
+	for _, cfg := range cfgs {
+		if cfg.Name == "" || cfg.URL == "" {
+			return nil, errors.Errorf("webhook needs a name and an url:%+v", cfg)
+		}
+		if names[cfg.Name] {
+			return nil, errors.Errorf("duplicate webhook name:%v", cfg.Name)
+		}
+		names[cfg.Name] = true
+		cfg.Format = strings.ToLower(cfg.Format)
+		switch cfg.Format {
+		case "":
+			cfg.Format = FormatJSON
+		case FormatJSON, FormatSlack, FormatDiscord:
+		default:
+			return nil, errors.Errorf("unknown webhook:%v format:%v", cfg.Name, cfg.Format)
+		}
+		notifiers = append(notifiers, &Webhook{cfg: cfg, client: client})
+	}
+	return notifiers, nil
+}
+
+func (self *Webhook) Name() string {
+	return self.cfg.Name
+}
+
+func (self *Webhook) Notify(ctx context.Context, alert Alert) error {
+	payload, err := self.payload(alert)
+	if err != nil {
+		return permanentError{errors.Wrap(err, "encoding payload")}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, self.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return permanentError{errors.Wrap(err, "creating request")}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "posting alert")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	err = errors.Errorf("webhook response status:%v body:%s", resp.Status, body)
+	// Client errors won't change on retry, except the rate limits.
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+	return err
+}
+
+func (self *Webhook) payload(alert Alert) ([]byte, error) {
+	switch self.cfg.Format {
+	case FormatSlack:
+		return json.Marshal(map[string]string{"text": message(alert, "*")})
+	case FormatDiscord:
+		content := message(alert, "**")
+		if len(content) > discordMaxContent {
+			content = content[:discordMaxContent]
+		}
+		return json.Marshal(map[string]string{"content": content})
+	default:
+		return json.Marshal(alert)
+	}
+}
+
+// message formats the alert as chat text with the summary in bold and a line for every label.
+func message(alert Alert, bold string) string {
+	keys := make([]string, 0, len(alert.Labels))
+	for k := range alert.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := []string{bold + alert.Summary + bold}
+	for _, k := range keys {
+		lines = append(lines, k+": "+alert.Labels[k])
+	}
+	return strings.Join(lines, "\n")
+}
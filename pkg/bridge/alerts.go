@@ -0,0 +1,52 @@
+package bridge
+
+import (
+	"context"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/pkg/errors"
+)
+
+// RecordAlertDelivery adds the delivery attempt to the alerts delivery log.
+func (self *Store) RecordAlertDelivery(d types.AlertDelivery) error {
+	p := influxdb2.NewPointWithMeasurement("alert_delivery").
+		AddTag("notifier", d.Notifier).
+		AddTag("status", d.Status).
+		AddField("key", d.Key).
+		AddField("alert", d.Alert).
+		AddField("attempts", int64(d.Attempts)).
+		SetTime(d.Time)
+	if d.Error != "" {
+		p.AddField("error", d.Error)
+	}
+	return self.writePoint(p)
+}
+
+// AlertDelivered returns whether the alert was delivered to the notifier within the given window.
+func (self *Store) AlertDelivered(ctx context.Context, key, notifier string, window time.Duration) (bool, error) {
+	query := `from(bucket: "my-bucket")
+	|> range(start: -` + fluxDuration(window) + `)
+	|> filter(fn: (r) => r["_measurement"] == "alert_delivery")
+	|> filter(fn: (r) => r["notifier"] == ` + FluxString(notifier) + `)
+	|> filter(fn: (r) => r["status"] == ` + FluxString(types.DeliveryDelivered) + `)
+	|> filter(fn: (r) => r["_field"] == "key")
+	|> filter(fn: (r) => r["_value"] == ` + FluxString(key) + `)
+	|> group()
+	|> count()`
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return false, errors.Wrap(err, "querying alert deliveries")
+	}
+	defer result.Close()
+
+	var count int64
+	if result.Next() {
+		count = toInt(result.Record().Value())
+	}
+	if result.Err() != nil {
+		return false, errors.Wrap(result.Err(), "reading alert deliveries")
+	}
+	return count > 0, nil
+}
@@ -1,6 +1,11 @@
 package bsciotex
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
 
 const ComponentName = "bsciotex"
 
@@ -19,5 +24,19 @@ const blockLimitBeforeCommit = uint64(4999)
 
 type Config struct {
 	LogLevel string
-	Timeout  uint
+	bridge.TrackerConfig
+	// TVLInterval between the tvl updates.
+	TVLInterval format.Duration
+	// MaxPriceAge is the oldest price accepted when valuing the tvl in usd.
+	MaxPriceAge format.Duration
+}
+
+func (self Config) Validate() error {
+	if err := self.TrackerConfig.Validate(); err != nil {
+		return err
+	}
+	if self.TVLInterval.Duration <= 0 {
+		return errors.New("tvl interval needs to be positive")
+	}
+	return nil
 }
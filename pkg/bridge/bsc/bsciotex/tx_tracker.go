@@ -8,13 +8,15 @@ import (
 
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/contracts/tokenCashier"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/ethereum"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/logging"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/metrics"
 	typ "github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
 	"github.com/davecgh/go-spew/spew"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/pkg/errors"
 
 	log "github.com/go-kit/kit/log"
@@ -26,20 +28,23 @@ type TransactionTracker struct {
 	cfg    Config
 	ctx    context.Context
 	cncl   context.CancelFunc
-	client *ethclient.Client
+	client ethereum.Client
 	store  *bridge.Store
 	// Map: token address ->  token symbol.
 	tokens map[string]bridge.ERC20
 }
 
-func NewTransactionTracker(ctx context.Context, client *ethclient.Client, logger log.Logger, cfg Config, store *bridge.Store) (*TransactionTracker, error) {
+func NewTransactionTracker(ctx context.Context, client ethereum.Client, logger log.Logger, cfg Config, store *bridge.Store) (*TransactionTracker, error) {
 	filterLog, err := logging.ApplyFilter(cfg.LogLevel, logger)
 	if err != nil {
 		return nil, errors.Wrap(err, "apply filter logger")
 	}
 	logger = log.With(filterLog, "component", ComponentName)
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validating config")
+	}
 	// Getting tokens.
-	ctxGetToken, cnclGetToken := context.WithTimeout(ctx, 10*time.Second)
+	ctxGetToken, cnclGetToken := context.WithTimeout(ctx, cfg.Timeout.Duration)
 	defer cnclGetToken()
 
 	tokens, err := bridge.GetTokenList(ctxGetToken, client, logger, StandardTokenListAddress, MintableTokenListAddress)
@@ -67,12 +72,14 @@ func (self *TransactionTracker) Stop() {
 func (self *TransactionTracker) Start() error {
 	level.Debug(self.logger).Log("msg", "eth tx tracker started")
 	// Ethereum blocktime ticker.
-	ticker := time.NewTicker(20 * time.Second)
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
+	// Failed checks in a row, the next check waits longer after every one.
+	var failures int
 	for {
 		select {
 		case <-self.ctx.Done():
 			return nil
-		case <-ticker.C:
+		case <-self.cfg.Next(ticker.C, failures):
 		}
 		var (
 			fromBlockNo, toBlockNo *big.Int
@@ -87,16 +94,18 @@ func (self *TransactionTracker) Start() error {
 			// Look ahead one block to make sure we didn't miss any new invoices.
 			fromBlockNo = lastCheckedBlockNo
 		}
-		// Calculating head block number minus 18.
+		// Head block, only the blocks with enough confirmations are checked.
 		header, err := self.client.HeaderByNumber(self.ctx, nil)
 		if err != nil {
 			level.Error(self.logger).Log("msg", "getting latest block header", "err", err)
+			failures++
 			continue
 		}
+		metrics.HeadBlock.WithLabelValues(ComponentName).Set(float64(header.Number.Uint64()))
 
 		// Min block to loop over.
 		min := math.Min(float64(fromBlockNo.Uint64()+blockLimitBeforeCommit),
-			float64(header.Number.Uint64()))
+			float64(self.cfg.SafeHead(header.Number.Uint64())))
 		toBlockNo = big.NewInt(int64(min))
 
 		if toBlockNo.Cmp(fromBlockNo) == -1 {
@@ -108,15 +117,18 @@ func (self *TransactionTracker) Start() error {
 			"fromBlockNo", fromBlockNo,
 			"toBlockNo", toBlockNo,
 		)
-		txs, err := self.traverse(fromBlockNo, toBlockNo)
+		txs, err := self.Traverse(fromBlockNo, toBlockNo)
 		if err != nil {
 			level.Error(self.logger).Log("msg", "traversing the eth blockchain",
 				"err", err,
 				"fromBlockNo", fromBlockNo,
 				"toBlockNo", toBlockNo,
 			)
+			failures++
 			continue
 		}
+		failures = 0
+		metrics.BlocksScanned.WithLabelValues(ComponentName).Add(float64(toBlockNo.Uint64() - fromBlockNo.Uint64() + 1))
 		level.Info(self.logger).Log("msg",
 			"new transactions count",
 			"count", len(txs),
@@ -137,19 +149,33 @@ func (self *TransactionTracker) Start() error {
 					"err", err,
 					"lastCheckedBlockNo", toBlockNo,
 				)
+			} else {
+				metrics.LastCheckedBlock.WithLabelValues(ComponentName).Set(float64(toBlockNo.Uint64()))
 			}
 		}
 	}
 }
 
-func (self *TransactionTracker) traverse(fromBlockNo, toBlockNo *big.Int) ([]typ.Transaction, error) {
+// Tokens returns the addresses of the tokens in the token lists.
+func (self *TransactionTracker) Tokens() []common.Address {
+	tokens := make([]common.Address, 0, len(self.tokens))
+	for addr := range self.tokens {
+		tokens = append(tokens, common.HexToAddress(addr))
+	}
+	return tokens
+}
+
+// Traverse returns the transfers of the block range without recording them.
+func (self *TransactionTracker) Traverse(fromBlockNo, toBlockNo *big.Int) ([]typ.Transaction, error) {
 	txs := make([]typ.Transaction, 0)
+	// Map: block number -> header, the events of a block share it.
+	headers := make(map[uint64]*types.Header)
 
 	tokenCashierFilterer, err := tokenCashier.NewTokenCashierFilterer(TokenCashierAddress, self.client)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting tokenCashierFilterer")
 	}
-	ctx, cncl := context.WithTimeout(self.ctx, 10*time.Second)
+	ctx, cncl := context.WithTimeout(self.ctx, self.cfg.Timeout.Duration)
 	defer cncl()
 	level.Info(self.logger).Log("msg",
 		"filtering Receipt events",
@@ -176,15 +202,15 @@ func (self *TransactionTracker) traverse(fromBlockNo, toBlockNo *big.Int) ([]typ
 		level.Info(self.logger).Log("msg",
 			"handle event", "event", iter.Event.Raw.TxHash.String(),
 		)
-		ctx, cncl := context.WithTimeout(self.ctx, 2*time.Second)
-		defer cncl()
+		ctx, cncl := context.WithTimeout(self.ctx, self.cfg.CallTimeout.Duration)
 		decimals, err := bridge.GetTokenDecimals(ctx, self.client, iter.Event.Token)
+		cncl()
 		if err != nil {
 			return nil, errors.Wrap(err, "getting token symbol")
 		}
-		ctx, cncl = context.WithTimeout(self.ctx, 2*time.Second)
-		defer cncl()
+		ctx, cncl = context.WithTimeout(self.ctx, self.cfg.CallTimeout.Duration)
 		symbol, err := bridge.GetTokenSymbol(ctx, self.client, iter.Event.Token)
+		cncl()
 		if err != nil {
 			return nil, errors.Wrap(err, "getting token symbol")
 		}
@@ -194,25 +220,36 @@ func (self *TransactionTracker) traverse(fromBlockNo, toBlockNo *big.Int) ([]typ
 		// Apply decimals.
 		amount, _ := big.NewFloat(0).Quo(transferValue, big.NewFloat(math.Pow10(int(decimals)))).Float64()
 
-		// Getting block metadata.
-		// ctx, _ = context.WithTimeout(self.ctx, 10*time.Second)
-		block, err := self.client.BlockByNumber(self.ctx, big.NewInt(int64(iter.Event.Raw.BlockNumber)))
-		if err != nil {
-			return nil, errors.Wrap(err, "getting block by number")
+		// Getting block metadata, once per block.
+		header, ok := headers[iter.Event.Raw.BlockNumber]
+		if !ok {
+			ctx, cncl = context.WithTimeout(self.ctx, self.cfg.CallTimeout.Duration)
+			block, err := self.client.BlockByNumber(ctx, new(big.Int).SetUint64(iter.Event.Raw.BlockNumber))
+			cncl()
+			if err != nil {
+				return nil, errors.Wrap(err, "getting block by number")
+			}
+			header = block.Header()
+			headers[iter.Event.Raw.BlockNumber] = header
 		}
 
 		tx := typ.Transaction{
 			Amount:     amount,
-			BlockNo:    block.Header().Number.Uint64(),
+			Fee:        bridge.NativeAmount(iter.Event.Fee),
+			BlockNo:    header.Number.Uint64(),
 			Hash:       iter.Event.Raw.TxHash.String(),
+			Token:      iter.Event.Token.String(),
+			DepositID:  iter.Event.Id.String(),
 			To:         iter.Event.Recipient.String(),
 			Symbol:     symbol,
 			Bridge:     typ.BscIoteX,
 			BridgeSide: typ.FromLeft,
 			From:       iter.Event.Sender.String(),
-			Timestamp:  block.Header().Time,
+			LogIndex:   iter.Event.Raw.Index,
+			Timestamp:  header.Time,
 		}
 		txs = append(txs, tx)
+		metrics.EventsDecoded.WithLabelValues(ComponentName).Inc()
 	}
 
 	return txs, nil
@@ -1,8 +1,11 @@
 package iotexbsc
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/ethereum/go-ethereum/common"
+)
 
-const ComponentName = "iotexeth"
+const ComponentName = "iotexbsc"
 
 var TokenCashierAddress = common.HexToAddress("0x14bf347a597aac623240ae7ac8383ae198966277")
 
@@ -14,5 +17,5 @@ const blockLimitBeforeCommit = uint64(10000)
 
 type Config struct {
 	LogLevel string
-	Timeout  uint
+	bridge.TrackerConfig
 }
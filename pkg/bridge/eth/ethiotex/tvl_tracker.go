@@ -5,11 +5,11 @@ import (
 	"time"
 
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/ethereum"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/logging"
 	typ "github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/pkg/errors"
 
 	log "github.com/go-kit/kit/log"
@@ -21,20 +21,24 @@ type TVLTracker struct {
 	cfg    Config
 	ctx    context.Context
 	cncl   context.CancelFunc
-	client *ethclient.Client
+	client ethereum.Client
 	store  *bridge.Store
+	prices bridge.PriceSource
 	// Map: token address ->  token symbol.
 	tokens map[string]bridge.ERC20
 }
 
-func NewTVLTracker(ctx context.Context, client *ethclient.Client, logger log.Logger, cfg Config, store *bridge.Store) (*TVLTracker, error) {
+func NewTVLTracker(ctx context.Context, client ethereum.Client, logger log.Logger, cfg Config, store *bridge.Store, prices bridge.PriceSource) (*TVLTracker, error) {
 	filterLog, err := logging.ApplyFilter(cfg.LogLevel, logger)
 	if err != nil {
 		return nil, errors.Wrap(err, "apply filter logger")
 	}
 	logger = log.With(filterLog, "component", ComponentName)
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validating config")
+	}
 	// Getting tokens.
-	ctxGetToken, cnclGetToken := context.WithTimeout(ctx, 10*time.Second)
+	ctxGetToken, cnclGetToken := context.WithTimeout(ctx, cfg.Timeout.Duration)
 	defer cnclGetToken()
 	tokens, err := bridge.GetTokenList(ctxGetToken, client, logger, StandardTokenListAddress, ProxyTokenListAddress)
 	if err != nil {
@@ -47,6 +51,7 @@ func NewTVLTracker(ctx context.Context, client *ethclient.Client, logger log.Log
 		ctx:    ctx,
 		cncl:   cncl,
 		store:  store,
+		prices: prices,
 
 		client: client,
 		tokens: tokens,
@@ -61,24 +66,9 @@ func (self *TVLTracker) Stop() {
 func (self *TVLTracker) Start() error {
 	level.Debug(self.logger).Log("msg", "tvl tracker started")
 
-	// Ethereum blocktime ticker.
-	// Update tvl every 10 minutes by default.
-	ticker := time.NewTicker(10 * time.Minute)
+	ticker := time.NewTicker(self.cfg.TVLInterval.Duration)
 	for {
-		tvlData := make([]typ.TVLData, 0)
-		for addr, erc20 := range self.tokens {
-			//ctx, _ := context.WithTimeout(self.ctx, 10*time.Second)
-			tvl, err := bridge.GetTVL(self.ctx, self.client, common.HexToAddress(addr), TokenSafeAddress)
-			if err != nil {
-				level.Error(self.logger).Log("msg", "getting tvl", "token", erc20.Symbol, "err", err)
-			}
-			tvlData = append(tvlData, typ.TVLData{
-				Value:   tvl,
-				Network: typ.NetEthereum,
-				Symbol:  erc20.Symbol,
-			})
-
-		}
+		tvlData := self.ReadTVL()
 		err := self.store.UpdateTVL(tvlData)
 		if err != nil {
 			level.Error(self.logger).Log("msg", "recording tvl", "err", err)
@@ -91,3 +81,29 @@ func (self *TVLTracker) Start() error {
 		}
 	}
 }
+
+// ReadTVL reads the locked amount of every token from the token safe without recording it.
+func (self *TVLTracker) ReadTVL() []typ.TVLData {
+	tvlData := make([]typ.TVLData, 0)
+	for addr, erc20 := range self.tokens {
+		//ctx, _ := context.WithTimeout(self.ctx, 10*time.Second)
+		tvl, err := bridge.GetTVL(self.ctx, self.client, common.HexToAddress(addr), TokenSafeAddress)
+		if err != nil {
+			level.Error(self.logger).Log("msg", "getting tvl", "token", erc20.Symbol, "err", err)
+		}
+		// Refuse to value the tvl with an old price.
+		price, err := self.prices.Latest(erc20.Symbol, self.cfg.MaxPriceAge.Duration)
+		if err != nil {
+			level.Warn(self.logger).Log("msg", "no recent price for tvl in usd", "token", erc20.Symbol, "err", err)
+		}
+		tvlData = append(tvlData, typ.TVLData{
+			Value:    tvl,
+			ValueUSD: tvl * price,
+			Network:  typ.NetEthereum,
+			Symbol:   erc20.Symbol,
+			Token:    common.HexToAddress(addr).Hex(),
+		})
+
+	}
+	return tvlData
+}
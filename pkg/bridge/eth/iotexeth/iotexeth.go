@@ -1,6 +1,9 @@
 package iotexeth
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/ethereum/go-ethereum/common"
+)
 
 const ComponentName = "iotexeth"
 
@@ -24,5 +27,5 @@ const blockLimitBeforeCommit = uint64(10000)
 
 type Config struct {
 	LogLevel string
-	Timeout  uint
+	bridge.TrackerConfig
 }
@@ -0,0 +1,140 @@
+package bridge
+
+import (
+	"sync"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+)
+
+type EventType string
+
+const (
+	EventTransfer EventType = "transfer"
+	EventTVL      EventType = "tvl"
+	EventPrice    EventType = "price"
+)
+
+// Event is published for every transfer, tvl and price committed to the store.
+// Only the field of the event type is set.
+type Event struct {
+	// ID increases with every event, it starts from 1 on every restart.
+	ID       uint64
+	Type     EventType
+	Time     time.Time
+	Transfer *types.Transaction
+	TVL      *types.TVLData
+	Price    *types.PricePoint
+}
+
+// subscriberBuffer is how many events a subscriber can fall behind before it is dropped.
+const subscriberBuffer = 256
+
+// Hub fans out the store events to the subscribers and keeps the latest events
+// so that subscribers can resume from the last event they received.
+type Hub struct {
+	// epoch is the start time of the process in milliseconds, it tells the ids of the restarts apart.
+	epoch  int64
+	mtx    sync.Mutex
+	lastID uint64
+	// Ring buffer of the latest events.
+	buffer []Event
+	next   int
+	full   bool
+	subs   map[chan Event]struct{}
+}
+
+func NewHub(size int) *Hub {
+	if size < 1 {
+		size = 1
+	}
+	return &Hub{
+		epoch:  time.Now().UnixNano() / int64(time.Millisecond),
+		buffer: make([]Event, size),
+		subs:   make(map[chan Event]struct{}),
+	}
+}
+
+// Publish assigns the event id and sends it to all subscribers.
+// Subscribers that can't keep up are dropped and need to resubscribe from their last event.
+func (self *Hub) Publish(e Event) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	self.lastID++
+	e.ID = self.lastID
+	self.buffer[self.next] = e
+	self.next = (self.next + 1) % len(self.buffer)
+	if self.next == 0 {
+		self.full = true
+	}
+
+	for ch := range self.subs {
+		select {
+		case ch <- e:
+		default:
+			delete(self.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns the buffered events after lastID and a channel with the new events.
+// complete is false when some events after lastID are no longer buffered.
+// The channel is closed by cancel or when the subscriber falls behind.
+func (self *Hub) Subscribe(lastID uint64) (backlog []Event, events <-chan Event, complete bool, cancel func()) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	complete = true
+	if lastID > 0 && lastID < self.lastID {
+		backlog = self.since(lastID)
+		complete = len(backlog) > 0 && backlog[0].ID == lastID+1
+	} else if lastID > self.lastID {
+		// The id is from before a restart.
+		complete = false
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	self.subs[ch] = struct{}{}
+	cancel = func() {
+		self.mtx.Lock()
+		defer self.mtx.Unlock()
+		if _, ok := self.subs[ch]; ok {
+			delete(self.subs, ch)
+			close(ch)
+		}
+	}
+	return backlog, ch, complete, cancel
+}
+
+// Epoch is the start time of the process in milliseconds, the event ids are only unique within it.
+func (self *Hub) Epoch() int64 {
+	return self.epoch
+}
+
+// Resume subscribes after an event id of any process.
+// The backlog is incomplete when the id is from another epoch.
+func (self *Hub) Resume(epoch int64, lastID uint64) (backlog []Event, events <-chan Event, complete bool, cancel func()) {
+	if epoch == self.epoch || (epoch == 0 && lastID == 0) {
+		return self.Subscribe(lastID)
+	}
+	_, events, _, cancel = self.Subscribe(0)
+	return nil, events, false, cancel
+}
+
+// since returns the buffered events after the id, oldest first.
+func (self *Hub) since(id uint64) []Event {
+	start, count := 0, self.next
+	if self.full {
+		start, count = self.next, len(self.buffer)
+	}
+	out := make([]Event, 0)
+	for i := 0; i < count; i++ {
+		e := self.buffer[(start+i)%len(self.buffer)]
+		if e.ID > id {
+			out = append(out, e)
+		}
+	}
+	return out
+}
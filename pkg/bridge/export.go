@@ -0,0 +1,118 @@
+package bridge
+
+import (
+	"context"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// eachChunk is the time range read at once by the Each functions,
+// the db sorts the rows of a chunk so a range of any size doesn't need to fit in its memory.
+const eachChunk = 7 * 24 * time.Hour
+
+// EachTransfer calls fn for every stored transfer in the query range, oldest first.
+// The rows are streamed from the db so the range can be of any size.
+func (self *Store) EachTransfer(ctx context.Context, q types.Query, fn func(types.Transaction) error) error {
+	return self.eachChunk(ctx, q, "tx", "transfers", func(values map[string]interface{}) error {
+		return fn(recordToTx(values))
+	})
+}
+
+// EachTVL calls fn for every stored tvl snapshot in the query range, oldest first.
+func (self *Store) EachTVL(ctx context.Context, q types.Query, fn func(types.TVLPoint) error) error {
+	return self.eachChunk(ctx, q, "tvl", "tvl", func(values map[string]interface{}) error {
+		t, _ := values["_time"].(time.Time)
+		return fn(types.TVLPoint{
+			Time:     t,
+			Network:  types.Network(toString(values["network"])),
+			Symbol:   toString(values["symbol"]),
+			Token:    toString(values["token"]),
+			Value:    toFloat(values["tvl"]),
+			ValueUSD: toFloat(values["tvl_usd"]),
+			HasUSD:   values["tvl_usd"] != nil,
+		})
+	})
+}
+
+// EachPrice calls fn for every stored price in the query range, oldest first.
+func (self *Store) EachPrice(ctx context.Context, q types.Query, fn func(types.PricePoint) error) error {
+	return self.eachChunk(ctx, q, "price", "prices", func(values map[string]interface{}) error {
+		t, _ := values["_time"].(time.Time)
+		return fn(types.PricePoint{
+			Time:   t,
+			Symbol: toString(values["symbol"]),
+			Source: toString(values["source"]),
+			Value:  toFloat(values["price"]),
+			Age:    time.Duration(toFloat(values["age"]) * float64(time.Second)),
+		})
+	})
+}
+
+// eachChunk calls fn for every point of the measurement in the query range, oldest first,
+// reading the range in chunks from its first point.
+func (self *Store) eachChunk(ctx context.Context, q types.Query, measurement, name string, fn func(map[string]interface{}) error) error {
+	first, err := self.firstTime(ctx, q, measurement)
+	if err != nil {
+		return errors.Wrapf(err, "getting the first of the %v", name)
+	}
+	if first.IsZero() {
+		return nil
+	}
+	// The range is formatted in seconds so the chunks need to start at a second.
+	if start := first.Truncate(time.Second); start.After(q.From) {
+		q.From = start
+	}
+	for start := q.From; start.Before(q.To); start = start.Add(eachChunk) {
+		chunk := q
+		chunk.From, chunk.To = start, start.Add(eachChunk)
+		if chunk.To.After(q.To) {
+			chunk.To = q.To
+		}
+		query := `from(bucket: "my-bucket")` + fluxRange(chunk) + `
+	|> filter(fn: (r) => r["_measurement"] == ` + FluxString(measurement) + `)
+	|> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+	|> group()
+	|> sort(columns: ["_time"])`
+		if err := self.each(ctx, query, name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// firstTime returns the time of the first point of the measurement in the query range, zero when there is none.
+func (self *Store) firstTime(ctx context.Context, q types.Query, measurement string) (time.Time, error) {
+	query := `from(bucket: "my-bucket")` + fluxRange(q) + `
+	|> filter(fn: (r) => r["_measurement"] == ` + FluxString(measurement) + `)
+	|> first()
+	|> keep(columns: ["_time"])
+	|> group()
+	|> sort(columns: ["_time"])
+	|> limit(n: 1)`
+	var first time.Time
+	err := self.each(ctx, query, "first "+measurement, func(values map[string]interface{}) error {
+		first, _ = values["_time"].(time.Time)
+		return nil
+	})
+	return first, err
+}
+
+func (self *Store) each(ctx context.Context, query, name string, fn func(map[string]interface{}) error) error {
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return errors.Wrapf(err, "querying %v", name)
+	}
+	defer result.Close()
+
+	for result.Next() {
+		if err := fn(result.Record().Values()); err != nil {
+			return err
+		}
+	}
+	if result.Err() != nil {
+		return errors.Wrapf(result.Err(), "reading %v", name)
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package iotexpoly
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/ethereum/go-ethereum/common"
+)
 
 const ComponentName = "iotexpoly"
 
@@ -18,5 +21,5 @@ const blockLimitBeforeCommit = uint64(10000)
 
 type Config struct {
 	LogLevel string
-	Timeout  uint
+	bridge.TrackerConfig
 }
@@ -0,0 +1,487 @@
+package bridge
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// FluxString quotes the value as a flux string literal, with the interpolations escaped.
+func FluxString(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	value = strings.Replace(value, `${`, `\${`, -1)
+	return `"` + value + `"`
+}
+
+// fluxRange returns the range and the tag filters of the query.
+func fluxRange(q types.Query) string {
+	flux := `
+	|> range(start: ` + q.From.UTC().Format(time.RFC3339) + `, stop: ` + q.To.UTC().Format(time.RFC3339) + `)`
+	filters := []struct{ tag, value string }{
+		{"bridge", string(q.Bridge)},
+		{"bridge_side", string(q.Side)},
+		{"network", string(q.Network)},
+		{"symbol", q.Symbol},
+	}
+	for _, f := range filters {
+		if f.value != "" {
+			flux += `
+	|> filter(fn: (r) => r["` + f.tag + `"] == ` + FluxString(f.value) + `)`
+		}
+	}
+	return flux
+}
+
+// fluxDuration formats the duration as a flux duration literal.
+func fluxDuration(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+}
+
+// toFloat converts the numeric values returned by influxdb to a float.
+func toFloat(v interface{}) float64 {
+	switch value := v.(type) {
+	case float64:
+		return value
+	case int64:
+		return float64(value)
+	case uint64:
+		return float64(value)
+	default:
+		return 0
+	}
+}
+
+func toInt(v interface{}) int64 {
+	switch value := v.(type) {
+	case int64:
+		return value
+	case uint64:
+		return int64(value)
+	case float64:
+		return int64(value)
+	default:
+		return 0
+	}
+}
+
+func toString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// Volume returns the bridged amounts and the transactions count per interval
+// for every bridge, side and symbol.
+func (self *Store) Volume(ctx context.Context, q types.Query) ([]types.VolumePoint, error) {
+	query := `data = from(bucket: "my-bucket")` + fluxRange(q) + `
+	|> filter(fn: (r) => r["_measurement"] == "tx")
+	|> filter(fn: (r) => r["_field"] == "amount" or r["_field"] == "amount_usd")
+	|> group(columns: ["bridge", "bridge_side", "symbol", "_field"])
+	|> sort(columns: ["_time"])
+sums = data
+	|> aggregateWindow(every: ` + fluxDuration(q.Interval) + `, fn: sum, createEmpty: false)
+counts = data
+	|> filter(fn: (r) => r["_field"] == "amount")
+	|> aggregateWindow(every: ` + fluxDuration(q.Interval) + `, fn: count, createEmpty: false)
+	|> set(key: "_field", value: "count")
+union(tables: [sums, counts])
+	|> group(columns: ["bridge", "bridge_side", "symbol"])
+	|> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+	|> sort(columns: ["_time"])`
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying volume")
+	}
+	defer result.Close()
+
+	points := make([]types.VolumePoint, 0)
+	for result.Next() {
+		r := result.Record()
+		points = append(points, types.VolumePoint{
+			Time:       r.Time(),
+			Bridge:     types.Bridge(toString(r.ValueByKey("bridge"))),
+			BridgeSide: types.BridgeSide(toString(r.ValueByKey("bridge_side"))),
+			Symbol:     toString(r.ValueByKey("symbol")),
+			Amount:     toFloat(r.ValueByKey("amount")),
+			AmountUSD:  toFloat(r.ValueByKey("amount_usd")),
+			Count:      toInt(r.ValueByKey("count")),
+		})
+	}
+	if result.Err() != nil {
+		return nil, errors.Wrap(result.Err(), "reading volume")
+	}
+	return points, nil
+}
+
+// TVL returns the last total value locked per interval for every network and symbol.
+func (self *Store) TVL(ctx context.Context, q types.Query) ([]types.TVLPoint, error) {
+	query := `from(bucket: "my-bucket")` + fluxRange(q) + `
+	|> filter(fn: (r) => r["_measurement"] == "tvl")
+	|> filter(fn: (r) => r["_field"] == "tvl" or r["_field"] == "tvl_usd")
+	|> group(columns: ["network", "symbol", "_field"])
+	|> sort(columns: ["_time"])
+	|> aggregateWindow(every: ` + fluxDuration(q.Interval) + `, fn: last, createEmpty: false)
+	|> group(columns: ["network", "symbol"])
+	|> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+	|> sort(columns: ["_time"])`
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying tvl")
+	}
+	defer result.Close()
+
+	points := make([]types.TVLPoint, 0)
+	for result.Next() {
+		r := result.Record()
+		points = append(points, types.TVLPoint{
+			Time:     r.Time(),
+			Network:  types.Network(toString(r.ValueByKey("network"))),
+			Symbol:   toString(r.ValueByKey("symbol")),
+			Value:    toFloat(r.ValueByKey("tvl")),
+			ValueUSD: toFloat(r.ValueByKey("tvl_usd")),
+			HasUSD:   r.ValueByKey("tvl_usd") != nil,
+		})
+	}
+	if result.Err() != nil {
+		return nil, errors.Wrap(result.Err(), "reading tvl")
+	}
+	return points, nil
+}
+
+// Prices returns the mean price per interval for every symbol.
+func (self *Store) Prices(ctx context.Context, q types.Query) ([]types.PricePoint, error) {
+	query := `from(bucket: "my-bucket")` + fluxRange(q) + `
+	|> filter(fn: (r) => r["_measurement"] == "price")
+	|> filter(fn: (r) => r["_field"] == "price")
+	|> group(columns: ["symbol"])
+	|> sort(columns: ["_time"])
+	|> aggregateWindow(every: ` + fluxDuration(q.Interval) + `, fn: mean, createEmpty: false)`
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying prices")
+	}
+	defer result.Close()
+
+	points := make([]types.PricePoint, 0)
+	for result.Next() {
+		r := result.Record()
+		points = append(points, types.PricePoint{
+			Time:   r.Time(),
+			Symbol: toString(r.ValueByKey("symbol")),
+			Value:  toFloat(r.Value()),
+		})
+	}
+	if result.Err() != nil {
+		return nil, errors.Wrap(result.Err(), "reading prices")
+	}
+	return points, nil
+}
+
+// Transfers returns the latest transactions first, at most q.Limit of them.
+func (self *Store) Transfers(ctx context.Context, q types.Query) ([]types.Transaction, error) {
+	query := `from(bucket: "my-bucket")` + fluxRange(q) + `
+	|> filter(fn: (r) => r["_measurement"] == "tx")
+	|> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+	|> group()
+	|> sort(columns: ["_time"], desc: true)
+	|> limit(n: ` + strconv.Itoa(q.Limit) + `)`
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying transfers")
+	}
+	defer result.Close()
+
+	txs := make([]types.Transaction, 0)
+	for result.Next() {
+		txs = append(txs, recordToTx(result.Record().Values()))
+	}
+	if result.Err() != nil {
+		return nil, errors.Wrap(result.Err(), "reading transfers")
+	}
+	return txs, nil
+}
+
+// recordToTx converts a pivoted tx record to a transaction.
+func recordToTx(values map[string]interface{}) types.Transaction {
+	var (
+		ts    uint64
+		index uint
+	)
+	if t, ok := values["_time"].(time.Time); ok {
+		ts, index = uint64(t.Unix()), uint(t.Nanosecond())
+	}
+	return types.Transaction{
+		Bridge:     types.Bridge(toString(values["bridge"])),
+		BridgeSide: types.BridgeSide(toString(values["bridge_side"])),
+		Symbol:     toString(values["symbol"]),
+		From:       toString(values["from"]),
+		To:         toString(values["to"]),
+		Hash:       toString(values["hash"]),
+		Token:      toString(values["token"]),
+		DepositID:  toString(values["deposit_id"]),
+		BlockNo:    uint64(toInt(values["block_number"])),
+		Amount:     toFloat(values["amount"]),
+		AmountUSD:  toFloat(values["amount_usd"]),
+		Fee:        toFloat(values["fee"]),
+		Timestamp:  ts,
+		LogIndex:   index,
+	}
+}
+
+// SearchTransfers returns the transfers that match all the search filters,
+// ordered by time, hash and receipt id and starting after the search cursor.
+// The receipt id orders the receipts of the same tx.
+func (self *Store) SearchTransfers(ctx context.Context, s types.TransferSearch) ([]types.Transaction, error) {
+	order, cmp := "true", "<"
+	if s.Ascending {
+		order, cmp = "false", ">"
+	}
+	query := `import "strings"
+from(bucket: "my-bucket")` + fluxRange(s.Query) + `
+	|> filter(fn: (r) => r["_measurement"] == "tx")`
+	// The addresses are tags so they are filtered before the pivot, as stored they are checksummed.
+	for _, f := range []struct{ tag, value string }{
+		{"from", s.Sender},
+		{"to", s.Recipient},
+	} {
+		if f.value != "" {
+			query += `
+	|> filter(fn: (r) => r["` + f.tag + `"] == ` + FluxString(f.value) + `)`
+		}
+	}
+	if s.Address != "" {
+		addr := FluxString(s.Address)
+		query += `
+	|> filter(fn: (r) => r["from"] == ` + addr + ` or r["to"] == ` + addr + `)`
+	}
+	query += `
+	|> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+	|> group()
+	|> map(fn: (r) => ({r with
+		hash: if exists r.hash then r.hash else "",
+		deposit_id: if exists r.deposit_id then r.deposit_id else "",
+	}))`
+	filters := []struct{ column, value string }{
+		{"hash", s.Hash},
+		{"deposit_id", s.DepositID},
+	}
+	for _, f := range filters {
+		if f.value != "" {
+			query += `
+	|> filter(fn: (r) => exists r["` + f.column + `"] and strings.toLower(v: r["` + f.column + `"]) == ` + FluxString(strings.ToLower(f.value)) + `)`
+		}
+	}
+	if s.Token != "" {
+		token := FluxString(strings.ToLower(s.Token))
+		query += `
+	|> filter(fn: (r) => strings.toLower(v: r["symbol"]) == ` + token + ` or (exists r["token"] and strings.toLower(v: r["token"]) == ` + token + `))`
+	}
+	if s.Cursor != nil {
+		t := s.Cursor.Time.UTC().Format(time.RFC3339Nano)
+		hash, id := FluxString(s.Cursor.Hash), FluxString(s.Cursor.DepositID)
+		query += `
+	|> filter(fn: (r) => r._time ` + cmp + ` ` + t + `
+		or (r._time == ` + t + ` and r.hash ` + cmp + ` ` + hash + `)
+		or (r._time == ` + t + ` and r.hash == ` + hash + ` and r.deposit_id ` + cmp + ` ` + id + `))`
+	}
+	query += `
+	|> sort(columns: ["_time", "hash", "deposit_id"], desc: ` + order + `)
+	|> limit(n: ` + strconv.Itoa(s.Limit) + `)`
+
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying transfers search")
+	}
+	defer result.Close()
+
+	txs := make([]types.Transaction, 0)
+	for result.Next() {
+		txs = append(txs, recordToTx(result.Record().Values()))
+	}
+	if result.Err() != nil {
+		return nil, errors.Wrap(result.Err(), "reading transfers search")
+	}
+	return txs, nil
+}
+
+// AddressActivity returns the totals of the transfers sent and received by the address in all the history,
+// aggregated by the db. The address is checksummed like the stored addresses.
+// The transfers to the address itself are only counted as sent.
+func (self *Store) AddressActivity(ctx context.Context, address string) ([]types.AddressActivity, error) {
+	addr := FluxString(address)
+	activity := make(map[string]*types.AddressActivity)
+	for _, side := range []struct {
+		filter, counterparty string
+		deposit              bool
+	}{
+		{`r["from"] == ` + addr, "to", true},
+		{`r["to"] == ` + addr + ` and r["from"] != ` + addr, "from", false},
+	} {
+		query := `data = from(bucket: "my-bucket")
+	|> range(start: 0)
+	|> filter(fn: (r) => r["_measurement"] == "tx")
+	|> filter(fn: (r) => ` + side.filter + `)
+	|> filter(fn: (r) => r["_field"] == "amount" or r["_field"] == "amount_usd")
+	|> group(columns: ["bridge", "symbol", "` + side.counterparty + `", "_field"])
+	|> sort(columns: ["_time"])
+data |> sum() |> yield(name: "sum")
+amounts = data |> filter(fn: (r) => r["_field"] == "amount")
+amounts |> count() |> yield(name: "count")
+amounts |> first() |> yield(name: "first")
+amounts |> last() |> yield(name: "last")`
+		result, err := self.readAPI.Query(ctx, query)
+		if err != nil {
+			return nil, errors.Wrap(err, "querying address activity")
+		}
+		for result.Next() {
+			record := result.Record()
+			bridge, symbol := toString(record.ValueByKey("bridge")), toString(record.ValueByKey("symbol"))
+			counterparty := toString(record.ValueByKey(side.counterparty))
+			key := strings.Join([]string{strconv.FormatBool(side.deposit), bridge, symbol, counterparty}, ":")
+			a, ok := activity[key]
+			if !ok {
+				a = &types.AddressActivity{Bridge: types.Bridge(bridge), Symbol: symbol, Counterparty: counterparty, Deposit: side.deposit}
+				activity[key] = a
+			}
+			// The result column is the name of the yield.
+			switch toString(record.ValueByKey("result")) {
+			case "sum":
+				if record.Field() == "amount" {
+					a.Amount = toFloat(record.Value())
+				} else {
+					a.AmountUSD = toFloat(record.Value())
+				}
+			case "count":
+				a.Count = toInt(record.Value())
+			case "first":
+				a.First = record.Time()
+			case "last":
+				a.Last = record.Time()
+			}
+		}
+		err = result.Err()
+		result.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "reading address activity")
+		}
+	}
+
+	out := make([]types.AddressActivity, 0, len(activity))
+	for _, a := range activity {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].First.Before(out[j].First) })
+	return out, nil
+}
+
+// LockedUSD returns the usd value locked on the network at the given time,
+// the number of locked tokens and when the value was last updated.
+func (self *Store) LockedUSD(ctx context.Context, network types.Network, at time.Time) (float64, int, time.Time, error) {
+	query := `from(bucket: "my-bucket")
+	|> range(start: ` + at.Add(-24*time.Hour).UTC().Format(time.RFC3339) + `, stop: ` + at.UTC().Format(time.RFC3339) + `)
+	|> filter(fn: (r) => r["_measurement"] == "tvl")
+	|> filter(fn: (r) => r["_field"] == "tvl_usd")
+	|> filter(fn: (r) => r["network"] == ` + FluxString(string(network)) + `)
+	|> group(columns: ["symbol"])
+	|> sort(columns: ["_time"])
+	|> last()`
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return 0, 0, time.Time{}, errors.Wrap(err, "querying locked usd")
+	}
+	defer result.Close()
+
+	var (
+		locked      float64
+		tokens      int
+		lastUpdated time.Time
+	)
+	for result.Next() {
+		locked += toFloat(result.Record().Value())
+		tokens++
+		if result.Record().Time().After(lastUpdated) {
+			lastUpdated = result.Record().Time()
+		}
+	}
+	if result.Err() != nil {
+		return 0, 0, time.Time{}, errors.Wrap(result.Err(), "reading locked usd")
+	}
+	return locked, tokens, lastUpdated, nil
+}
+
+// VolumeUSD returns the usd value bridged through the bridge in the given range.
+func (self *Store) VolumeUSD(ctx context.Context, bridge types.Bridge, from, to time.Time) (float64, error) {
+	query := `from(bucket: "my-bucket")` + fluxRange(types.Query{From: from, To: to, Bridge: bridge}) + `
+	|> filter(fn: (r) => r["_measurement"] == "tx")
+	|> filter(fn: (r) => r["_field"] == "amount_usd")
+	|> group()
+	|> sum()`
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return 0, errors.Wrap(err, "querying volume usd")
+	}
+	defer result.Close()
+
+	var volume float64
+	if result.Next() {
+		volume = toFloat(result.Record().Value())
+	}
+	if result.Err() != nil {
+		return 0, errors.Wrap(result.Err(), "reading volume usd")
+	}
+	return volume, nil
+}
+
+// Fees returns the total fees of the transfers in the range,
+// in the native coin of the deposit chain so only one side of a bridge adds up.
+func (self *Store) Fees(ctx context.Context, q types.Query) (float64, error) {
+	query := `from(bucket: "my-bucket")` + fluxRange(q) + `
+	|> filter(fn: (r) => r["_measurement"] == "tx")
+	|> filter(fn: (r) => r["_field"] == "fee")
+	|> group()
+	|> sum()`
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return 0, errors.Wrap(err, "querying fees")
+	}
+	defer result.Close()
+
+	var fees float64
+	if result.Next() {
+		fees = toFloat(result.Record().Value())
+	}
+	if result.Err() != nil {
+		return 0, errors.Wrap(result.Err(), "reading fees")
+	}
+	return fees, nil
+}
+
+// Holders returns the number of distinct addresses that used the bridge until the given time.
+func (self *Store) Holders(ctx context.Context, bridge types.Bridge, at time.Time) (int64, error) {
+	query := `from(bucket: "my-bucket")` + fluxRange(types.Query{From: time.Unix(0, 0), To: at, Bridge: bridge}) + `
+	|> filter(fn: (r) => r["_measurement"] == "tx")
+	|> filter(fn: (r) => r["_field"] == "amount")
+	|> keep(columns: ["from"])
+	|> group()
+	|> distinct(column: "from")
+	|> count()`
+	result, err := self.readAPI.Query(ctx, query)
+	if err != nil {
+		return 0, errors.Wrap(err, "querying holders")
+	}
+	defer result.Close()
+
+	var holders int64
+	if result.Next() {
+		holders = toInt(result.Record().Value())
+	}
+	if result.Err() != nil {
+		return 0, errors.Wrap(result.Err(), "reading holders")
+	}
+	return holders, nil
+}
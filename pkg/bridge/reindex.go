@@ -0,0 +1,154 @@
+package bridge
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// ReindexResult counts the changes made by a re-index.
+type ReindexResult struct {
+	Added     int
+	Changed   int
+	Removed   int
+	Unchanged int
+}
+
+// transferKey identifies a transfer by its hash and receipt id.
+func transferKey(tx types.Transaction) string {
+	return tx.Hash + ":" + tx.DepositID
+}
+
+// pointKey identifies the stored point of a transfer,
+// used for the transfers recorded before their hash was stored.
+func pointKey(tx types.Transaction) string {
+	return strings.Join([]string{CanonicalSymbolName(tx.Symbol), tx.From, strconv.FormatUint(tx.Timestamp, 10)}, ":")
+}
+
+// storedKey identifies a stored point of a bridge side.
+func storedKey(tx types.Transaction) string {
+	return strings.Join([]string{tx.Symbol, tx.From, strconv.FormatInt(tx.Time().UnixNano(), 10)}, ":")
+}
+
+// sameTransfer compares the values decoded from the chain, the usd value depends on the prices so it is ignored.
+func sameTransfer(stored, tx types.Transaction) bool {
+	return stored.Timestamp == tx.Timestamp &&
+		stored.LogIndex == tx.LogIndex &&
+		stored.BlockNo == tx.BlockNo &&
+		stored.Symbol == CanonicalSymbolName(tx.Symbol) &&
+		stored.From == tx.From &&
+		stored.To == tx.To &&
+		stored.Token == tx.Token &&
+		stored.Amount == tx.Amount &&
+		stored.Fee == tx.Fee
+}
+
+// ReplaceTransfers replaces the stored transfers of the query bridge side
+// in the block range with the given transfers.
+// The query range needs to cover the times of the first and the last block.
+// Transfers are matched by their hash and receipt id and every transfer has its own point,
+// so running it again with the same transfers changes nothing.
+// The last checked block of the trackers isn't changed.
+func (self *Store) ReplaceTransfers(ctx context.Context, q types.Query, fromBlock, toBlock uint64, txs []types.Transaction) (ReindexResult, error) {
+	var result ReindexResult
+	if q.Bridge == "" || q.Side == "" {
+		return result, errors.New("re-index needs a bridge and a side")
+	}
+
+	stored := make(map[string]types.Transaction)
+	legacy := make(map[string]types.Transaction)
+	err := self.EachTransfer(ctx, q, func(tx types.Transaction) error {
+		switch {
+		case tx.Hash == "":
+			legacy[pointKey(tx)] = tx
+		case tx.BlockNo >= fromBlock && tx.BlockNo <= toBlock:
+			stored[transferKey(tx)] = tx
+		}
+		return nil
+	})
+	if err != nil {
+		return result, errors.Wrap(err, "reading stored transfers")
+	}
+	untagged, err := self.untaggedTransfers(ctx, q)
+	if err != nil {
+		return result, errors.Wrap(err, "reading the transfers without a recipient tag")
+	}
+
+	// Every point is deleted before the transfers are written,
+	// so a deleted point can't remove a written transfer with the same tags and time.
+	write := make([]types.Transaction, 0)
+	remove := make([]types.Transaction, 0)
+	for _, tx := range txs {
+		if old, ok := stored[transferKey(tx)]; ok {
+			delete(stored, transferKey(tx))
+			// The points recorded before the recipient tag are written again with it.
+			if sameTransfer(old, tx) && !untagged[storedKey(old)] {
+				result.Unchanged++
+				continue
+			}
+			// The point is identified by its tags and time so it can't be overwritten when they change.
+			remove = append(remove, old)
+			result.Changed++
+		} else if old, ok := legacy[pointKey(tx)]; ok {
+			// Rewritten at the time offset by the log index, with the missing fields.
+			delete(legacy, pointKey(tx))
+			remove = append(remove, old)
+			result.Changed++
+		} else {
+			result.Added++
+		}
+		write = append(write, tx)
+	}
+	for _, removed := range []map[string]types.Transaction{stored, legacy} {
+		for _, tx := range removed {
+			remove = append(remove, tx)
+			result.Removed++
+		}
+	}
+
+	for _, tx := range remove {
+		if err := self.deleteTransfer(ctx, tx); err != nil {
+			return result, err
+		}
+	}
+	if err := self.RecordTxs(write); err != nil {
+		return result, errors.Wrap(err, "recording transfers")
+	}
+	return result, nil
+}
+
+// untaggedTransfers returns the stored points in the query range without the recipient tag.
+// Map: storedKey -> true.
+func (self *Store) untaggedTransfers(ctx context.Context, q types.Query) (map[string]bool, error) {
+	query := `from(bucket: "my-bucket")` + fluxRange(q) + `
+	|> filter(fn: (r) => r["_measurement"] == "tx")
+	|> filter(fn: (r) => r["_field"] == "amount" and not exists r["to"])
+	|> keep(columns: ["_time", "symbol", "from"])`
+	untagged := make(map[string]bool)
+	err := self.each(ctx, query, "untagged transfers", func(values map[string]interface{}) error {
+		untagged[storedKey(recordToTx(values))] = true
+		return nil
+	})
+	return untagged, err
+}
+
+// deleteTransfer deletes the stored point of the transfer.
+func (self *Store) deleteTransfer(ctx context.Context, tx types.Transaction) error {
+	ts := tx.Time()
+	predicate := `_measurement="tx"`
+	for _, tag := range []struct{ key, value string }{
+		{"bridge", string(tx.Bridge)},
+		{"bridge_side", string(tx.BridgeSide)},
+		{"symbol", tx.Symbol},
+		{"from", tx.From},
+	} {
+		predicate += ` AND ` + tag.key + `=` + FluxString(tag.value)
+	}
+	if err := self.tsdb.DeleteAPI().DeleteWithName(ctx, "my-org", "my-bucket", ts, ts, predicate); err != nil {
+		return errors.Wrapf(err, "deleting transfer hash:%v", tx.Hash)
+	}
+	return nil
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 
 	"math/big"
+	"strings"
 	"time"
 
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/logging"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/metrics"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
 )
 
 const ComponentName = "store"
@@ -20,13 +25,32 @@ const ComponentName = "store"
 type Config struct {
 	LogLevel string
 	Timeout  uint
+	// EventsBuffer is the number of latest events kept for the subscribers that reconnect.
+	EventsBuffer int
+	// MaxPriceAge is the oldest price accepted when valuing the new transfers in usd.
+	MaxPriceAge format.Duration
 }
+
+// Validate checks the events buffer and the max price age.
+func (self Config) Validate() error {
+	if self.EventsBuffer < 0 {
+		return errors.New("events buffer can't be negative")
+	}
+	if self.MaxPriceAge.Duration <= 0 {
+		return errors.New("max price age needs to be positive")
+	}
+	return nil
+}
+
 type Store struct {
 	ctx      context.Context
+	cfg      Config
 	tsdb     influxdb2.Client
 	writeAPI api.WriteAPIBlocking
 	readAPI  api.QueryAPI
 	logger   log.Logger
+	events   *Hub
+	prices   PriceSource
 }
 
 func NewSore(ctx context.Context, logger log.Logger, cfg Config, tsdb influxdb2.Client) (*Store, error) {
@@ -38,14 +62,35 @@ func NewSore(ctx context.Context, logger log.Logger, cfg Config, tsdb influxdb2.
 	writeAPI := tsdb.WriteAPIBlocking("my-org", "my-bucket")
 	readAPI := tsdb.QueryAPI("my-org")
 	return &Store{
+		cfg:      cfg,
 		tsdb:     tsdb,
 		writeAPI: writeAPI,
 		readAPI:  readAPI,
 		ctx:      ctx,
 		logger:   logger,
+		events:   NewHub(cfg.EventsBuffer),
 	}, nil
 }
 
+// SetPrices sets the latest prices used to value the new transfers in usd.
+// Without it the transfers are recorded without a usd value until RecomputeTxsUSD.
+func (self *Store) SetPrices(prices PriceSource) {
+	self.prices = prices
+}
+
+// Events returns the hub that publishes every committed transfer, tvl and price.
+func (self *Store) Events() *Hub {
+	return self.events
+}
+
+// writePoint saves the point and records the write latency and failures of its measurement.
+func (self *Store) writePoint(p *write.Point) error {
+	start := time.Now()
+	err := self.writeAPI.WritePoint(context.Background(), p)
+	metrics.ObserveStoreWrite(p.Name(), start, err)
+	return err
+}
+
 // LastCheckedBlockNo returns last checked block number.
 func (self *Store) LastCheckedBlockNo(network, peer types.Network) (*big.Int, error) {
 	// Get parser flux query result
@@ -98,8 +143,49 @@ func (self *Store) GetAllSymbols() ([]string, error) {
 	return symbols, nil
 }
 
+// GetAllTokens returns all tokens with a recorded tvl.
+func (self *Store) GetAllTokens() ([]types.Token, error) {
+	query := `from(bucket: "my-bucket")
+	|> range(start: -10d)
+	|> filter(fn: (r) => r["_measurement"] == "tvl")
+	|> filter(fn: (r) => r["_field"] == "tvl")
+	|> filter(fn: (r) => exists r["token"])
+	|> last()`
+	result, err := self.readAPI.Query(context.Background(), query)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying tokens")
+	}
+
+	tokens := []types.Token{}
+	// Every series is a single token so last() returns one record per token.
+	for result.Next() {
+		network, _ := result.Record().ValueByKey("network").(string)
+		address, _ := result.Record().ValueByKey("token").(string)
+		symbol, _ := result.Record().ValueByKey("symbol").(string)
+		tokens = append(tokens, types.Token{
+			Network: types.Network(network),
+			Address: address,
+			Symbol:  symbol,
+		})
+	}
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+	return tokens, nil
+}
+
+// RecordTxs saves the transactions and enriches the recent ones with their usd value from the latest prices.
+// The older transactions are valued by RecomputeTxsUSD with the recorded prices.
 func (self *Store) RecordTxs(txs []types.Transaction) error {
 	for _, tx := range txs {
+		ts := tx.Time()
+		if tx.AmountUSD == 0 {
+			price, err := self.latestPrice(tx.Symbol, ts)
+			if err != nil {
+				level.Debug(self.logger).Log("msg", "no usd price for tx", "symbol", tx.Symbol, "hash", tx.Hash, "err", err)
+			}
+			tx.AmountUSD = tx.Amount * price
+		}
 		// Create point using fluent style.
 		p := influxdb2.NewPointWithMeasurement("tx").
 			AddTag("bridge", string(tx.Bridge)).
@@ -107,22 +193,89 @@ func (self *Store) RecordTxs(txs []types.Transaction) error {
 			AddTag("symbol", CanonicalSymbolName(string(tx.Symbol))).
 			AddTag("from", string(tx.From)).
 			AddField("amount", tx.Amount).
-			SetTime(time.Unix(int64(tx.Timestamp), 0))
-		err := self.writeAPI.WritePoint(context.Background(), p)
+			SetTime(ts)
+		// The recipient is a tag like the sender so the address queries don't read all the transfers.
+		if tx.To != "" {
+			p.AddTag("to", tx.To)
+		}
+		if tx.AmountUSD != 0 {
+			p.AddField("amount_usd", tx.AmountUSD)
+		}
+		if tx.Fee != 0 {
+			p.AddField("fee", tx.Fee)
+		}
+		// Transfer details used by the search api.
+		for field, value := range map[string]string{
+			"hash":       tx.Hash,
+			"token":      tx.Token,
+			"deposit_id": tx.DepositID,
+		} {
+			if value != "" {
+				p.AddField(field, value)
+			}
+		}
+		if tx.BlockNo != 0 {
+			p.AddField("block_number", int64(tx.BlockNo))
+		}
+		err := self.writePoint(p)
 		if err != nil {
 			return err
 		}
+		tx := tx
+		self.events.Publish(Event{Type: EventTransfer, Time: ts, Transfer: &tx})
 	}
 	return nil
 }
 
-func (self *Store) RecordPrice(symbol string, price float64) error {
+// RecordPrice saves the symbol price along with the source that produced it
+// and the age of the price at the source.
+func (self *Store) RecordPrice(symbol string, price float64, source string, age time.Duration) error {
 	// Create point using fluent style.
 	p := influxdb2.NewPointWithMeasurement("price").
 		AddTag("symbol", symbol).
+		AddTag("source", source).
 		AddField("price", price).
+		AddField("age", age.Seconds()).
 		SetTime(time.Now())
-	err := self.writeAPI.WritePoint(context.Background(), p)
+	err := self.writePoint(p)
+	if err != nil {
+		return err
+	}
+	self.events.Publish(Event{Type: EventPrice, Time: p.Time(), Price: &types.PricePoint{
+		Time:   p.Time(),
+		Symbol: symbol,
+		Source: source,
+		Value:  price,
+		Age:    age,
+	}})
+	return nil
+}
+
+// RecordQuarantinedPrice saves a price that failed validation, for later inspection.
+func (self *Store) RecordQuarantinedPrice(symbol string, price float64, source, reason string) error {
+	// Create point using fluent style.
+	p := influxdb2.NewPointWithMeasurement("price_quarantine").
+		AddTag("symbol", symbol).
+		AddTag("source", source).
+		AddField("price", price).
+		AddField("reason", reason).
+		SetTime(time.Now())
+	err := self.writePoint(p)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RecordPriceAt saves the symbol price at the given time, used when backfilling past prices.
+func (self *Store) RecordPriceAt(symbol string, price float64, source string, ts time.Time) error {
+	// Create point using fluent style.
+	p := influxdb2.NewPointWithMeasurement("price").
+		AddTag("symbol", symbol).
+		AddTag("source", source).
+		AddField("price", price).
+		SetTime(ts)
+	err := self.writePoint(p)
 	if err != nil {
 		return err
 	}
@@ -136,7 +289,7 @@ func (self *Store) UpdateLastCheckedBlockNo(blockNo *big.Int, net types.Network,
 		AddTag("peer", string(peer)).
 		AddField("block_number", blockNo.Uint64()).
 		SetTime(time.Now())
-	err := self.writeAPI.WritePoint(context.Background(), p)
+	err := self.writePoint(p)
 	if err != nil {
 		return err
 	}
@@ -149,12 +302,133 @@ func (self *Store) UpdateTVL(tvls []types.TVLData) error {
 		p := influxdb2.NewPointWithMeasurement("tvl").
 			AddTag("network", string(tvl.Network)).
 			AddTag("symbol", string(tvl.Symbol)).
+			AddTag("token", tvl.Token).
 			AddField("tvl", tvl.Value).
 			SetTime(time.Now())
-		err := self.writeAPI.WritePoint(context.Background(), p)
+		if tvl.ValueUSD != 0 {
+			p.AddField("tvl_usd", tvl.ValueUSD)
+		}
+		err := self.writePoint(p)
 		if err != nil {
 			return err
 		}
+		tvl := tvl
+		self.events.Publish(Event{Type: EventTVL, Time: p.Time(), TVL: &tvl})
 	}
 	return nil
 }
+
+// priceLookback is how far back to look for a price before a given time.
+// It covers the gap between two daily backfilled prices.
+const priceLookback = 25 * time.Hour
+
+// latestPrice returns the latest price of the symbol when the transaction at the given time
+// is within the max price age, so the price is close to the one at the transaction time.
+func (self *Store) latestPrice(symbol string, ts time.Time) (float64, error) {
+	if self.prices == nil {
+		return 0, errors.New("no price source")
+	}
+	if time.Since(ts) > self.cfg.MaxPriceAge.Duration {
+		return 0, errors.Errorf("tx older than the max price age:%v", self.cfg.MaxPriceAge.Duration)
+	}
+	var err error
+	for _, alias := range PriceSymbols(symbol) {
+		var price float64
+		price, err = self.prices.Latest(alias, self.cfg.MaxPriceAge.Duration)
+		if err == nil {
+			return price, nil
+		}
+	}
+	return 0, err
+}
+
+// PriceAt returns the last recorded usd price of the symbol at or before the given time.
+func (self *Store) PriceAt(symbol string, ts time.Time) (float64, error) {
+	symbolFilter := make([]string, 0)
+	for _, alias := range PriceSymbols(symbol) {
+		symbolFilter = append(symbolFilter, `r["symbol"] == `+FluxString(alias))
+	}
+	query := `from(bucket: "my-bucket")
+	|> range(start: ` + ts.Add(-priceLookback).UTC().Format(time.RFC3339) + `, stop: ` + ts.Add(time.Second).UTC().Format(time.RFC3339) + `)
+	|> filter(fn: (r) => r["_measurement"] == "price")
+	|> filter(fn: (r) => r["_field"] == "price")
+	|> filter(fn: (r) => ` + strings.Join(symbolFilter, " or ") + `)
+	|> group()
+	|> last()`
+	result, err := self.readAPI.Query(context.Background(), query)
+	if err != nil {
+		return 0, errors.Wrap(err, "querying price")
+	}
+	if result.Next() {
+		price, ok := result.Record().Value().(float64)
+		if !ok {
+			return 0, errors.Errorf("unexpected price value:%v", result.Record().Value())
+		}
+		return price, nil
+	}
+	if result.Err() != nil {
+		return 0, result.Err()
+	}
+	return 0, errors.Errorf("no price for symbol:%v at:%v", symbol, ts)
+}
+
+// cachedPriceAt looks up the price once per symbol and hour.
+func (self *Store) cachedPriceAt(cache map[string]float64, symbol string, ts time.Time) (float64, error) {
+	key := CanonicalSymbolName(symbol) + ts.Truncate(time.Hour).String()
+	if price, ok := cache[key]; ok {
+		return price, nil
+	}
+	price, err := self.PriceAt(symbol, ts)
+	if err != nil {
+		return 0, err
+	}
+	cache[key] = price
+	return price, nil
+}
+
+// RecomputeTxsUSD rewrites the usd value of all stored transactions in the given range
+// using the recorded prices, for example after a price backfill.
+// It returns the number of updated transactions.
+func (self *Store) RecomputeTxsUSD(from, to time.Time) (int, error) {
+	query := `from(bucket: "my-bucket")
+	|> range(start: ` + from.UTC().Format(time.RFC3339) + `, stop: ` + to.UTC().Format(time.RFC3339) + `)
+	|> filter(fn: (r) => r["_measurement"] == "tx")
+	|> filter(fn: (r) => r["_field"] == "amount")`
+	result, err := self.readAPI.Query(context.Background(), query)
+	if err != nil {
+		return 0, errors.Wrap(err, "querying txs")
+	}
+
+	prices := make(map[string]float64)
+	var updated int
+	for result.Next() {
+		record := result.Record()
+		amount, ok := record.Value().(float64)
+		if !ok {
+			continue
+		}
+		symbol, _ := record.ValueByKey("symbol").(string)
+		price, err := self.cachedPriceAt(prices, symbol, record.Time())
+		if err != nil {
+			level.Debug(self.logger).Log("msg", "no usd price for tx", "symbol", symbol, "time", record.Time(), "err", err)
+			continue
+		}
+		// Same series and time so only the usd field is overwritten.
+		p := influxdb2.NewPointWithMeasurement("tx").
+			AddField("amount_usd", amount*price).
+			SetTime(record.Time())
+		for _, tag := range []string{"bridge", "bridge_side", "symbol", "from"} {
+			if v, ok := record.ValueByKey(tag).(string); ok {
+				p.AddTag(tag, v)
+			}
+		}
+		if err := self.writePoint(p); err != nil {
+			return updated, errors.Wrap(err, "writing tx usd value")
+		}
+		updated++
+	}
+	if result.Err() != nil {
+		return updated, result.Err()
+	}
+	return updated, nil
+}
@@ -0,0 +1,52 @@
+package bridge
+
+import (
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/ethereum"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/pkg/errors"
+)
+
+// TrackerConfig is the polling config of a tx tracker, tuned to the block time of its network.
+type TrackerConfig struct {
+	// Interval between the checks for new blocks.
+	Interval format.Duration
+	// Timeout of the calls over a block range, like filtering the events, or reading the token lists.
+	Timeout format.Duration
+	// CallTimeout of the calls made for every event, like reading the token symbol and decimals.
+	CallTimeout format.Duration
+	// Confirmations is the number of blocks a block needs on top of it before it is indexed.
+	Confirmations uint64
+	// MaxBackoff between the checks after failures in a row, the wait doubles from the Interval with every failure.
+	MaxBackoff format.Duration
+}
+
+func (self TrackerConfig) Validate() error {
+	if self.Interval.Duration <= 0 {
+		return errors.New("interval needs to be positive")
+	}
+	if self.Timeout.Duration <= 0 || self.CallTimeout.Duration <= 0 {
+		return errors.New("timeouts need to be positive")
+	}
+	if self.MaxBackoff.Duration < self.Interval.Duration {
+		return errors.New("max backoff can't be below the interval")
+	}
+	return nil
+}
+
+// SafeHead returns the last block with enough confirmations.
+func (self TrackerConfig) SafeHead(head uint64) uint64 {
+	if head < self.Confirmations {
+		return 0
+	}
+	return head - self.Confirmations
+}
+
+// Next returns the channel of the next check, the ticker or after failures in a row a backoff.
+func (self TrackerConfig) Next(tick <-chan time.Time, failures int) <-chan time.Time {
+	if failures == 0 {
+		return tick
+	}
+	return time.After(ethereum.Backoff(self.Interval.Duration, self.MaxBackoff.Duration, failures))
+}
@@ -6,12 +6,13 @@ import (
 	"math/big"
 
 	"strings"
+	"time"
 
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/contracts/erc20"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/contracts/tokenList"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	log "github.com/go-kit/kit/log"
 	"github.com/pkg/errors"
 )
@@ -25,13 +26,33 @@ func CanonicalSymbolName(symbol string) string {
 	return symbol
 }
 
+// PriceSymbols returns the symbols that may carry the price of the given symbol,
+// since prices are recorded with the token symbols as they are on chain.
+func PriceSymbols(symbol string) []string {
+	canonical := CanonicalSymbolName(symbol)
+	symbols := []string{canonical}
+	if symbol != canonical {
+		symbols = append(symbols, symbol)
+	}
+	if canonical == "ETH" && symbol != "WETH" {
+		symbols = append(symbols, "WETH")
+	}
+	return symbols
+}
+
+// PriceSource provides the latest usd price of a symbol.
+// It fails when the price is older than maxAge so consumers don't value assets with stale prices.
+type PriceSource interface {
+	Latest(symbol string, maxAge time.Duration) (float64, error)
+}
+
 type ERC20 struct {
 	Symbol   string
 	Decimals uint8
 }
 
 // getTokenList gathers a map of: token address -> token symbol.
-func GetTokenListMethod2(ctx context.Context, client *ethclient.Client, logger log.Logger, standardTokenListAddress, proxyTokenListAddress common.Address, standardTokenListStart, proxyTokenListStart uint64) (map[string]ERC20, error) {
+func GetTokenListMethod2(ctx context.Context, client ethereum.Client, logger log.Logger, standardTokenListAddress, proxyTokenListAddress common.Address, standardTokenListStart, proxyTokenListStart uint64) (map[string]ERC20, error) {
 	out := make(map[string]ERC20)
 	// Getting standard token list.
 	tokenListCaller, err := tokenList.NewTokenListFilterer(standardTokenListAddress, client)
@@ -87,7 +108,7 @@ func GetTokenListMethod2(ctx context.Context, client *ethclient.Client, logger l
 }
 
 // getTokenList gathers a map of: token address -> token symbol.
-func GetTokenList(ctx context.Context, client *ethclient.Client, logger log.Logger, standardTokenListAddress, proxyTokenListAddress common.Address) (map[string]ERC20, error) {
+func GetTokenList(ctx context.Context, client ethereum.Client, logger log.Logger, standardTokenListAddress, proxyTokenListAddress common.Address) (map[string]ERC20, error) {
 	out := make(map[string]ERC20)
 	// Getting standard token list.
 	tokenListCaller, err := tokenList.NewTokenListCaller(standardTokenListAddress, client)
@@ -153,7 +174,35 @@ func GetTokenList(ctx context.Context, client *ethclient.Client, logger log.Logg
 
 }
 
-func GetTVL(ctx context.Context, client *ethclient.Client, tokenAddress, tokenSafeAddress common.Address) (float64, error) {
+// NativeAmount converts an amount of the smallest unit of a native coin with 18 decimals, like wei, to coins.
+func NativeAmount(amount *big.Int) float64 {
+	coins, _ := big.NewFloat(0).Quo(big.NewFloat(0).SetInt(amount), big.NewFloat(math.Pow10(18))).Float64()
+	return coins
+}
+
+// BlockAt returns the first block with a time at or after the given time, or the head block.
+func BlockAt(ctx context.Context, client ethereum.Client, t time.Time) (uint64, error) {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "getting head block")
+	}
+	lo, hi := uint64(0), head.Number.Uint64()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, errors.Wrapf(err, "getting block:%v", mid)
+		}
+		if int64(header.Time) < t.Unix() {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+func GetTVL(ctx context.Context, client ethereum.Client, tokenAddress, tokenSafeAddress common.Address) (float64, error) {
 	// Getting standard token list.
 	erc20Caller, err := erc20.NewErc20Caller(tokenAddress, client)
 	if err != nil {
@@ -174,7 +223,7 @@ func GetTVL(ctx context.Context, client *ethclient.Client, tokenAddress, tokenSa
 	return amount, nil
 }
 
-func GetTokenSymbol(ctx context.Context, client *ethclient.Client, token common.Address) (string, error) {
+func GetTokenSymbol(ctx context.Context, client ethereum.Client, token common.Address) (string, error) {
 	// Getting token symbol.
 	erc20Caller, err := erc20.NewErc20Caller(token, client)
 	if err != nil {
@@ -184,7 +233,7 @@ func GetTokenSymbol(ctx context.Context, client *ethclient.Client, token common.
 	return erc20Caller.Symbol(&bind.CallOpts{})
 }
 
-func GetTokenDecimals(ctx context.Context, client *ethclient.Client, token common.Address) (uint8, error) {
+func GetTokenDecimals(ctx context.Context, client ethereum.Client, token common.Address) (uint8, error) {
 	// Getting token decimals.
 	erc20Caller, err := erc20.NewErc20Caller(token, client)
 	if err != nil {
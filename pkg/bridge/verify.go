@@ -0,0 +1,139 @@
+package bridge
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/contracts/tokenCashier"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/ethereum"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// DepositGap is a range of receipt ids missing from the store.
+type DepositGap struct {
+	FromID uint64 `json:"fromId"`
+	ToID   uint64 `json:"toId"`
+	// FromBlock and ToBlock are the blocks of the stored receipts around the gap,
+	// re-indexing them fills the gap. Zero when the stored receipt has no block number.
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+}
+
+// TokenDeposits compares the deposits counter of the cashier for a token with the stored transfers.
+type TokenDeposits struct {
+	Token   string `json:"token"`
+	Symbol  string `json:"symbol,omitempty"`
+	OnChain uint64 `json:"onChain"`
+	Stored  int    `json:"stored"`
+	Missing uint64 `json:"missing"`
+	// Duplicates are the receipt ids stored more than once.
+	Duplicates []uint64     `json:"duplicates,omitempty"`
+	Gaps       []DepositGap `json:"gaps,omitempty"`
+}
+
+// DepositsReport is the completeness of the stored transfers of a bridge side.
+type DepositsReport struct {
+	Bridge types.Bridge     `json:"bridge"`
+	Side   types.BridgeSide `json:"side"`
+	// Block the counters were read at.
+	Block    uint64 `json:"block"`
+	Complete bool   `json:"complete"`
+	// WithoutID is the number of stored transfers recorded before their receipt id was stored.
+	WithoutID int             `json:"withoutId"`
+	Tokens    []TokenDeposits `json:"tokens"`
+}
+
+// VerifyDeposits compares the deposits counter of every token of the cashier with the stored transfers
+// of the bridge side and finds the gaps in the receipt ids, which are sequential per token starting at 1.
+// The counters are read at toBlock, the last block the transfers are stored for,
+// and fromBlock is the first block the cashier is tracked from.
+// Tokens without stored transfers are only checked when given.
+func (self *Store) VerifyDeposits(ctx context.Context, client ethereum.Client, cashier common.Address, bridge types.Bridge, side types.BridgeSide, fromBlock, toBlock uint64, tokens []common.Address) (DepositsReport, error) {
+	report := DepositsReport{Bridge: bridge, Side: side, Block: toBlock, Complete: true, Tokens: make([]TokenDeposits, 0)}
+
+	// Map: token -> receipt id -> blocks of the stored transfers.
+	stored := make(map[string]map[uint64][]uint64)
+	symbols := make(map[string]string)
+	for _, token := range tokens {
+		stored[token.Hex()] = make(map[uint64][]uint64)
+	}
+	err := self.EachTransfer(ctx, types.Query{From: time.Unix(0, 0), To: time.Now(), Bridge: bridge, Side: side}, func(tx types.Transaction) error {
+		id, err := strconv.ParseUint(tx.DepositID, 10, 64)
+		if tx.DepositID == "" || tx.Token == "" || err != nil {
+			report.WithoutID++
+			return nil
+		}
+		token := common.HexToAddress(tx.Token).Hex()
+		if _, ok := stored[token]; !ok {
+			stored[token] = make(map[uint64][]uint64)
+		}
+		stored[token][id] = append(stored[token][id], tx.BlockNo)
+		symbols[token] = tx.Symbol
+		return nil
+	})
+	if err != nil {
+		return report, errors.Wrap(err, "reading stored transfers")
+	}
+
+	caller, err := tokenCashier.NewTokenCashierCaller(cashier, client)
+	if err != nil {
+		return report, errors.Wrap(err, "creating token cashier caller")
+	}
+	for token, ids := range stored {
+		count, err := caller.Count(&bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(toBlock)}, common.HexToAddress(token))
+		if err != nil {
+			return report, errors.Wrapf(err, "getting deposits count of token:%v", token)
+		}
+		deposits := depositGaps(ids, count.Uint64(), fromBlock, toBlock)
+		deposits.Token, deposits.Symbol = token, symbols[token]
+		if deposits.Missing > 0 || len(deposits.Duplicates) > 0 || uint64(deposits.Stored) > deposits.OnChain {
+			report.Complete = false
+		}
+		report.Tokens = append(report.Tokens, deposits)
+	}
+	sort.Slice(report.Tokens, func(i, j int) bool { return report.Tokens[i].Token < report.Tokens[j].Token })
+	return report, nil
+}
+
+// depositGaps finds the ids from 1 to count that aren't in the stored ids.
+// The gaps before the first and after the last stored id are bound by fromBlock and toBlock.
+func depositGaps(ids map[uint64][]uint64, count, fromBlock, toBlock uint64) TokenDeposits {
+	deposits := TokenDeposits{OnChain: count, Duplicates: make([]uint64, 0), Gaps: make([]DepositGap, 0)}
+	sorted := make([]uint64, 0, len(ids))
+	for id, blocks := range ids {
+		deposits.Stored += len(blocks)
+		if len(blocks) > 1 {
+			deposits.Duplicates = append(deposits.Duplicates, id)
+		}
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	sort.Slice(deposits.Duplicates, func(i, j int) bool { return deposits.Duplicates[i] < deposits.Duplicates[j] })
+
+	var (
+		prev      uint64
+		prevBlock = fromBlock
+	)
+	for _, id := range sorted {
+		if id > count {
+			break
+		}
+		block := ids[id][0]
+		if id > prev+1 {
+			deposits.Gaps = append(deposits.Gaps, DepositGap{FromID: prev + 1, ToID: id - 1, FromBlock: prevBlock, ToBlock: block})
+			deposits.Missing += id - 1 - prev
+		}
+		prev, prevBlock = id, block
+	}
+	if count > prev {
+		deposits.Gaps = append(deposits.Gaps, DepositGap{FromID: prev + 1, ToID: count, FromBlock: prevBlock, ToBlock: toBlock})
+		deposits.Missing += count - prev
+	}
+	return deposits
+}
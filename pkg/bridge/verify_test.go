@@ -0,0 +1,77 @@
+package bridge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDepositGaps(t *testing.T) {
+	cases := []struct {
+		name     string
+		ids      map[uint64][]uint64
+		count    uint64
+		expected TokenDeposits
+	}{
+		{
+			name:     "complete",
+			ids:      map[uint64][]uint64{1: {100}, 2: {110}, 3: {120}},
+			count:    3,
+			expected: TokenDeposits{OnChain: 3, Stored: 3, Duplicates: []uint64{}, Gaps: []DepositGap{}},
+		},
+		{
+			name:  "nothing stored",
+			ids:   map[uint64][]uint64{},
+			count: 2,
+			expected: TokenDeposits{OnChain: 2, Missing: 2, Duplicates: []uint64{}, Gaps: []DepositGap{
+				{FromID: 1, ToID: 2, FromBlock: 10, ToBlock: 1000},
+			}},
+		},
+		{
+			name:  "leading gap",
+			ids:   map[uint64][]uint64{3: {120}, 4: {130}},
+			count: 4,
+			expected: TokenDeposits{OnChain: 4, Stored: 2, Missing: 2, Duplicates: []uint64{}, Gaps: []DepositGap{
+				{FromID: 1, ToID: 2, FromBlock: 10, ToBlock: 120},
+			}},
+		},
+		{
+			name:  "trailing gap",
+			ids:   map[uint64][]uint64{1: {100}, 2: {110}},
+			count: 5,
+			expected: TokenDeposits{OnChain: 5, Stored: 2, Missing: 3, Duplicates: []uint64{}, Gaps: []DepositGap{
+				{FromID: 3, ToID: 5, FromBlock: 110, ToBlock: 1000},
+			}},
+		},
+		{
+			name:  "gaps in the middle",
+			ids:   map[uint64][]uint64{1: {100}, 3: {120}, 7: {170}},
+			count: 7,
+			expected: TokenDeposits{OnChain: 7, Stored: 3, Missing: 4, Duplicates: []uint64{}, Gaps: []DepositGap{
+				{FromID: 2, ToID: 2, FromBlock: 100, ToBlock: 120},
+				{FromID: 4, ToID: 6, FromBlock: 120, ToBlock: 170},
+			}},
+		},
+		{
+			name:     "ids above the count",
+			ids:      map[uint64][]uint64{1: {100}, 2: {110}, 4: {130}},
+			count:    2,
+			expected: TokenDeposits{OnChain: 2, Stored: 3, Duplicates: []uint64{}, Gaps: []DepositGap{}},
+		},
+		{
+			name:  "duplicates",
+			ids:   map[uint64][]uint64{1: {100}, 2: {110, 110}, 3: {120}, 4: {130, 131}},
+			count: 5,
+			expected: TokenDeposits{OnChain: 5, Stored: 6, Missing: 1, Duplicates: []uint64{2, 4}, Gaps: []DepositGap{
+				{FromID: 5, ToID: 5, FromBlock: 130, ToBlock: 1000},
+			}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			deposits := depositGaps(c.ids, c.count, 10, 1000)
+			if !reflect.DeepEqual(deposits, c.expected) {
+				t.Fatalf("unexpected deposits:%+v, expected:%+v", deposits, c.expected)
+			}
+		})
+	}
+}
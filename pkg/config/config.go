@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/alert"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/bsc/bsciotex"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/bsc/iotexbsc"
@@ -15,9 +16,14 @@ import (
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/poly/iotexpoly"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge/poly/polyiotex"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/db"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/ethereum"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/health"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/logging"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/price"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/web"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/web/api"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/joho/godotenv"
@@ -28,6 +34,8 @@ import (
 
 type Config struct {
 	Web       web.Config
+	Health    health.Config
+	Nodes     ethereum.PoolConfig
 	EthIoTeX  ethiotex.Config
 	IoTeXEth  iotexeth.Config
 	IoTeXPoly iotexpoly.Config
@@ -35,6 +43,7 @@ type Config struct {
 	BscIoTeX  bsciotex.Config
 	IoTeXBsc  iotexbsc.Config
 	Price     price.Config
+	Alert     alert.Config
 	Db        db.Config
 	Bridge    bridge.Config
 	// EnvFile location that include all private details like private key etc.
@@ -47,6 +56,57 @@ var DefaultConfig = Config{
 		ListenHost:  "", // Listen on all addresses.
 		ListenPort:  9090,
 		ReadTimeout: format.Duration{Duration: 10 * time.Second},
+		Query: api.QueryConfig{
+			Enabled:      false,
+			Buckets:      []string{"my-bucket"},
+			Measurements: []string{"tx", "tvl", "price"},
+			MaxRange:     format.Duration{Duration: 90 * 24 * time.Hour},
+			MaxRows:      10000,
+			Timeout:      format.Duration{Duration: 10 * time.Second},
+		},
+		Cache: api.CacheConfig{
+			MaxEntries: 1000,
+			TTLs: map[string]format.Duration{
+				"volume":           {Duration: 5 * time.Minute},
+				"tvl":              {Duration: 5 * time.Minute},
+				"prices":           {Duration: 5 * time.Minute},
+				"transfers":        {Duration: time.Minute},
+				"transfers/search": {Duration: time.Minute},
+				"address":          {Duration: time.Minute},
+			},
+		},
+		Auth: api.AuthConfig{
+			Required:   false,
+			Rate:       5,
+			Burst:      20,
+			KeyIPRate:  50,
+			KeyIPBurst: 100,
+		},
+	},
+	Health: health.Config{
+		LogLevel: "info",
+		Timeout:  format.Duration{Duration: 5 * time.Second},
+		CacheTTL: format.Duration{Duration: 10 * time.Second},
+		MaxLag: map[types.Network]uint64{
+			types.NetEthereum: 50,
+			types.NetIoTeX:    200,
+			types.NetPolygon:  500,
+			types.NetBsc:      500,
+		},
+	},
+	Nodes: ethereum.PoolConfig{
+		CheckInterval: format.Duration{Duration: 30 * time.Second},
+		MaxLag: map[types.Network]uint64{
+			types.NetEthereum: 5,
+			types.NetIoTeX:    10,
+			types.NetPolygon:  30,
+			types.NetBsc:      20,
+		},
+		MaxFailures: 3,
+		Cooldown:    format.Duration{Duration: time.Minute},
+		Retries:     3,
+		Backoff:     format.Duration{Duration: time.Second},
+		MaxBackoff:  format.Duration{Duration: 30 * time.Second},
 	},
 	Db: db.Config{
 		LogLevel:      "info",
@@ -55,34 +115,130 @@ var DefaultConfig = Config{
 	},
 	EthIoTeX: ethiotex.Config{
 		LogLevel: "info",
-		Timeout:  3000,
+		TrackerConfig: bridge.TrackerConfig{
+			Interval:      format.Duration{Duration: 20 * time.Second},
+			Timeout:       format.Duration{Duration: 10 * time.Second},
+			CallTimeout:   format.Duration{Duration: 2 * time.Second},
+			MaxBackoff:    format.Duration{Duration: 5 * time.Minute},
+			Confirmations: 12,
+		},
+		TVLInterval: format.Duration{Duration: 10 * time.Minute},
+		MaxPriceAge: format.Duration{Duration: 10 * time.Minute},
 	},
 	IoTeXEth: iotexeth.Config{
 		LogLevel: "info",
-		Timeout:  3000,
+		TrackerConfig: bridge.TrackerConfig{
+			Interval:      format.Duration{Duration: 20 * time.Second},
+			Timeout:       format.Duration{Duration: 10 * time.Second},
+			CallTimeout:   format.Duration{Duration: 2 * time.Second},
+			MaxBackoff:    format.Duration{Duration: 5 * time.Minute},
+			Confirmations: 0,
+		},
 	},
 	IoTeXPoly: iotexpoly.Config{
 		LogLevel: "info",
-		Timeout:  3000,
+		TrackerConfig: bridge.TrackerConfig{
+			Interval:      format.Duration{Duration: 20 * time.Second},
+			Timeout:       format.Duration{Duration: 10 * time.Second},
+			CallTimeout:   format.Duration{Duration: 2 * time.Second},
+			MaxBackoff:    format.Duration{Duration: 5 * time.Minute},
+			Confirmations: 0,
+		},
 	},
 	PolyIoTeX: polyiotex.Config{
 		LogLevel: "info",
-		Timeout:  3000,
+		TrackerConfig: bridge.TrackerConfig{
+			Interval:      format.Duration{Duration: 20 * time.Second},
+			Timeout:       format.Duration{Duration: 10 * time.Second},
+			CallTimeout:   format.Duration{Duration: 2 * time.Second},
+			MaxBackoff:    format.Duration{Duration: 5 * time.Minute},
+			Confirmations: 128,
+		},
+		TVLInterval: format.Duration{Duration: 10 * time.Minute},
+		MaxPriceAge: format.Duration{Duration: 10 * time.Minute},
 	},
 	BscIoTeX: bsciotex.Config{
 		LogLevel: "info",
-		Timeout:  3000,
+		TrackerConfig: bridge.TrackerConfig{
+			Interval:      format.Duration{Duration: 20 * time.Second},
+			Timeout:       format.Duration{Duration: 10 * time.Second},
+			CallTimeout:   format.Duration{Duration: 2 * time.Second},
+			MaxBackoff:    format.Duration{Duration: 5 * time.Minute},
+			Confirmations: 15,
+		},
+		TVLInterval: format.Duration{Duration: 10 * time.Minute},
+		MaxPriceAge: format.Duration{Duration: 10 * time.Minute},
 	},
 	IoTeXBsc: iotexbsc.Config{
 		LogLevel: "info",
-		Timeout:  3000,
+		TrackerConfig: bridge.TrackerConfig{
+			Interval:      format.Duration{Duration: 20 * time.Second},
+			Timeout:       format.Duration{Duration: 10 * time.Second},
+			CallTimeout:   format.Duration{Duration: 2 * time.Second},
+			MaxBackoff:    format.Duration{Duration: 5 * time.Minute},
+			Confirmations: 0,
+		},
 	},
 	Price: price.Config{
-		LogLevel: "debug",
+		LogLevel:       "debug",
+		Interval:       format.Duration{Duration: 2 * time.Minute},
+		Timeout:        format.Duration{Duration: 30 * time.Second},
+		RequestTimeout: format.Duration{Duration: 10 * time.Second},
+		ResolveTimeout: format.Duration{Duration: 5 * time.Second},
+		HistoryTimeout: format.Duration{Duration: 5 * time.Minute},
+		Aggregation:    price.AggregationPriority,
+		Providers: []price.ProviderConfig{
+			{Type: price.ProviderCoinGecko},
+		},
+		Tokens: []price.TokenConfig{
+			{Network: types.NetEthereum, Address: "0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599", ID: "bitcoin"},
+			{Network: types.NetEthereum, Address: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", ID: "ethereum"},
+			{Network: types.NetEthereum, Address: "0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984", ID: "uniswap"},
+			{Network: types.NetEthereum, Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", ID: "usd-coin"},
+			{Network: types.NetEthereum, Address: "0x514910771AF9Ca656af840dff83E8264EcF986CA", ID: "chainlink"},
+			{Network: types.NetEthereum, Address: "0xdAC17F958D2ee523a2206206994597C13D831ec7", ID: "tether"},
+			{Network: types.NetEthereum, Address: "0x45804880De22913dAFE09f4980848ECE6EcbAf78", ID: "pax-gold"},
+			{Network: types.NetEthereum, Address: "0x6B3595068778DD592e39A122f4f5a5cF09C90fE2", ID: "sushi"},
+			{Network: types.NetEthereum, Address: "0x6B175474E89094C44Da98b954EedeAC495271d0F", ID: "dai"},
+			{Network: types.NetEthereum, Address: "0x7Fc66500c84A76Ad7e9c93437bFc5Ac33E2DDaE9", ID: "aave"},
+			{Network: types.NetBsc, Address: "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c", ID: "wbnb"},
+			{Network: types.NetBsc, Address: "0xe9e7CEA3DedcA5984780Bafc599bD69ADd087D56", ID: "binance-usd"},
+			{Network: types.NetPolygon, Address: "0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270", ID: "matic-network"},
+			{Network: types.NetPolygon, Address: "0x831753DD7087CaC61aB5644b308642cc1c33Dc13", ID: "quick"},
+		},
+		Validation: price.ValidationConfig{
+			MaxDeviation:  0.5,
+			Confirmations: 3,
+			Pegs: map[string]float64{
+				"USDT": 1,
+				"USDC": 1,
+				"DAI":  1,
+				"BUSD": 1,
+			},
+			MaxPegDeviation:    0.1,
+			MinSources:         1,
+			MaxSourceDeviation: 0.05,
+		},
 	},
-	Bridge: bridge.Config{
+	Alert: alert.Config{
 		LogLevel: "info",
-		Timeout:  3000,
+		Transfers: []alert.Threshold{
+			{USD: 1000000},
+		},
+		RulesInterval: format.Duration{Duration: time.Minute},
+		Delivery: alert.DeliveryConfig{
+			Retries:     5,
+			RetryDelay:  format.Duration{Duration: 10 * time.Second},
+			Timeout:     format.Duration{Duration: 10 * time.Second},
+			DedupWindow: format.Duration{Duration: 7 * 24 * time.Hour},
+			QueueSize:   1000,
+		},
+	},
+	Bridge: bridge.Config{
+		LogLevel:     "info",
+		Timeout:      3000,
+		EventsBuffer: 10000,
+		MaxPriceAge:  format.Duration{Duration: 10 * time.Minute},
 	},
 	EnvFile: ".env",
 }
@@ -135,3 +291,67 @@ func ParseConfig(logger log.Logger, path string) (*Config, error) {
 
 	return cfg, nil
 }
+
+// Validate checks the config of every component without connecting to any service.
+func (self *Config) Validate() error {
+	levels := []struct {
+		component string
+		level     string
+	}{
+		{web.ComponentName, self.Web.LogLevel},
+		{health.ComponentName, self.Health.LogLevel},
+		{ethiotex.ComponentName, self.EthIoTeX.LogLevel},
+		{iotexeth.ComponentName, self.IoTeXEth.LogLevel},
+		{iotexpoly.ComponentName, self.IoTeXPoly.LogLevel},
+		{polyiotex.ComponentName, self.PolyIoTeX.LogLevel},
+		{bsciotex.ComponentName, self.BscIoTeX.LogLevel},
+		{iotexbsc.ComponentName, self.IoTeXBsc.LogLevel},
+		{price.ComponentName, self.Price.LogLevel},
+		{alert.ComponentName, self.Alert.LogLevel},
+		{db.ComponentName, self.Db.LogLevel},
+		{bridge.ComponentName, self.Bridge.LogLevel},
+	}
+	for _, l := range levels {
+		if _, err := logging.ApplyFilter(l.level, log.NewNopLogger()); err != nil {
+			return errors.Wrapf(err, "%v log level", l.component)
+		}
+	}
+	trackers := []struct {
+		component string
+		network   types.Network
+		validate  func() error
+		tracker   bridge.TrackerConfig
+	}{
+		{ethiotex.ComponentName, types.NetEthereum, self.EthIoTeX.Validate, self.EthIoTeX.TrackerConfig},
+		{iotexeth.ComponentName, types.NetIoTeX, self.IoTeXEth.Validate, self.IoTeXEth.TrackerConfig},
+		{iotexpoly.ComponentName, types.NetIoTeX, self.IoTeXPoly.Validate, self.IoTeXPoly.TrackerConfig},
+		{polyiotex.ComponentName, types.NetPolygon, self.PolyIoTeX.Validate, self.PolyIoTeX.TrackerConfig},
+		{bsciotex.ComponentName, types.NetBsc, self.BscIoTeX.Validate, self.BscIoTeX.TrackerConfig},
+		{iotexbsc.ComponentName, types.NetIoTeX, self.IoTeXBsc.Validate, self.IoTeXBsc.TrackerConfig},
+	}
+	for _, t := range trackers {
+		if err := t.validate(); err != nil {
+			return errors.Wrap(err, t.component)
+		}
+		// A tracker is always at least its confirmations behind the head.
+		if maxLag := self.Health.MaxLag[t.network]; maxLag != 0 && maxLag <= t.tracker.Confirmations {
+			return errors.Errorf("health max lag:%v of %v needs to be more than the %v confirmations:%v", maxLag, t.network, t.component, t.tracker.Confirmations)
+		}
+	}
+	if err := self.Bridge.Validate(); err != nil {
+		return errors.Wrap(err, "bridge")
+	}
+	if err := self.Nodes.Validate(); err != nil {
+		return errors.Wrap(err, "nodes")
+	}
+	if err := self.Web.Auth.Validate(); err != nil {
+		return errors.Wrap(err, "web auth")
+	}
+	if err := self.Price.Validate(); err != nil {
+		return errors.Wrap(err, "price")
+	}
+	if err := self.Alert.Validate(); err != nil {
+		return errors.Wrap(err, "alert")
+	}
+	return nil
+}
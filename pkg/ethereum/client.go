@@ -131,8 +131,11 @@ func GetAccounts() ([]*Account, error) {
 }
 
 func NewClient(ctx context.Context, logger log.Logger) (*ethclient.Client, error) {
-	nodeURL := os.Getenv(NodeURLEnvName)
+	return Dial(ctx, logger, os.Getenv(NodeURLEnvName))
+}
 
+// Dial connects to an ethereum node and checks that it isn't syncing.
+func Dial(ctx context.Context, logger log.Logger, nodeURL string) (*ethclient.Client, error) {
 	client, err := ethclient.DialContext(ctx, nodeURL)
 	if err != nil {
 		return nil, errors.Wrap(err, "create rpc client instance")
@@ -151,7 +154,7 @@ func NewClient(ctx context.Context, logger log.Logger) (*ethclient.Client, error
 
 	id, err := client.NetworkID(ctx)
 	if err != nil {
-		return nil, level.Error(logger).Log("msg", "get nerwork ID", "err", err)
+		return nil, errors.Wrap(err, "getting network id")
 	}
 
 	level.Info(logger).Log("msg", "client created", "netID", id.String())
@@ -0,0 +1,78 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/metrics"
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Client is the part of the node client used by the bridge trackers.
+type Client interface {
+	bind.ContractCaller
+	bind.ContractFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+}
+
+// InstrumentedClient records the calls count, outcome and duration of every rpc method.
+type InstrumentedClient struct {
+	chain  string
+	client Client
+}
+
+func NewInstrumentedClient(chain string, client Client) *InstrumentedClient {
+	return &InstrumentedClient{chain: chain, client: client}
+}
+
+func (self *InstrumentedClient) observe(method string, start time.Time, err error) {
+	metrics.RPCCalls.WithLabelValues(self.chain, method, metrics.Outcome(err)).Inc()
+	metrics.RPCDuration.WithLabelValues(self.chain, method).Observe(time.Since(start).Seconds())
+}
+
+func (self *InstrumentedClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	start := time.Now()
+	code, err := self.client.CodeAt(ctx, contract, blockNumber)
+	self.observe("eth_getCode", start, err)
+	return code, err
+}
+
+func (self *InstrumentedClient) CallContract(ctx context.Context, call eth.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	start := time.Now()
+	out, err := self.client.CallContract(ctx, call, blockNumber)
+	self.observe("eth_call", start, err)
+	return out, err
+}
+
+func (self *InstrumentedClient) FilterLogs(ctx context.Context, query eth.FilterQuery) ([]types.Log, error) {
+	start := time.Now()
+	logs, err := self.client.FilterLogs(ctx, query)
+	self.observe("eth_getLogs", start, err)
+	return logs, err
+}
+
+func (self *InstrumentedClient) SubscribeFilterLogs(ctx context.Context, query eth.FilterQuery, ch chan<- types.Log) (eth.Subscription, error) {
+	start := time.Now()
+	sub, err := self.client.SubscribeFilterLogs(ctx, query, ch)
+	self.observe("eth_subscribe", start, err)
+	return sub, err
+}
+
+func (self *InstrumentedClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	start := time.Now()
+	header, err := self.client.HeaderByNumber(ctx, number)
+	self.observe("eth_getBlockByNumber", start, err)
+	return header, err
+}
+
+func (self *InstrumentedClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	start := time.Now()
+	block, err := self.client.BlockByNumber(ctx, number)
+	self.observe("eth_getBlockByNumber_full", start, err)
+	return block, err
+}
@@ -0,0 +1,89 @@
+package ethereum
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/metrics"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// Limits of the calls to a node endpoint, like the plan of a paid provider.
+type Limits struct {
+	// Rate of the calls per second, zero doesn't limit it.
+	Rate float64
+	// Burst of calls allowed above the rate, at least one.
+	Burst int
+	// DailyBudget is the number of calls per UTC day, zero doesn't limit it.
+	DailyBudget uint64
+}
+
+func (self Limits) Validate() error {
+	if self.Rate < 0 || self.Burst < 0 {
+		return errors.New("rate and burst can't be negative")
+	}
+	return nil
+}
+
+// ErrBudgetExhausted is returned for the calls to an endpoint that used its daily budget.
+var ErrBudgetExhausted = errors.New("daily request budget exhausted")
+
+// limiter paces the calls to an endpoint and counts them against its daily budget.
+type limiter struct {
+	chain  string
+	name   string
+	limits Limits
+	rate   *rate.Limiter
+
+	mtx  sync.Mutex
+	day  string
+	used uint64
+}
+
+func newLimiter(chain, name string, limits Limits) *limiter {
+	l := &limiter{chain: chain, name: name, limits: limits, rate: rate.NewLimiter(rate.Inf, 1)}
+	if limits.Rate > 0 {
+		burst := limits.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		l.rate = rate.NewLimiter(rate.Limit(limits.Rate), burst)
+	}
+	metrics.NodeBudgetLimit.WithLabelValues(chain, name).Set(float64(limits.DailyBudget))
+	return l
+}
+
+// take counts a call against the budget and waits until the rate allows it.
+func (self *limiter) take(ctx context.Context) error {
+	if err := self.spend(time.Now()); err != nil {
+		return err
+	}
+	start := time.Now()
+	if err := self.rate.Wait(ctx); err != nil {
+		return errors.Wrap(err, "waiting for the rate limit")
+	}
+	metrics.NodeRateLimitWait.WithLabelValues(self.chain, self.name).Add(time.Since(start).Seconds())
+	return nil
+}
+
+func (self *limiter) spend(now time.Time) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if day := now.UTC().Format("2006-01-02"); day != self.day {
+		self.day, self.used = day, 0
+	}
+	if self.limits.DailyBudget > 0 && self.used >= self.limits.DailyBudget {
+		return ErrBudgetExhausted
+	}
+	self.used++
+	metrics.NodeBudgetUsed.WithLabelValues(self.chain, self.name).Set(float64(self.used))
+	return nil
+}
+
+// nextDay is the start of the next UTC day, when the budgets are reset.
+func nextDay(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
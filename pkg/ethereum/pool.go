@@ -0,0 +1,414 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/metrics"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// PoolConfig of the node pools of all chains.
+type PoolConfig struct {
+	// CheckInterval between the head checks of every endpoint.
+	CheckInterval format.Duration
+	// MaxLag is how many blocks an endpoint can be behind the highest head of its chain before it isn't used.
+	MaxLag map[types.Network]uint64
+	// MaxFailures in a row before an endpoint isn't used for the Cooldown.
+	MaxFailures int
+	Cooldown    format.Duration
+	// Retries of a call after all endpoints failed with transient errors,
+	// waiting from Backoff up to MaxBackoff between them.
+	Retries    int
+	Backoff    format.Duration
+	MaxBackoff format.Duration
+	// Limits of the endpoints by their name, the host of the node url. Other endpoints aren't limited.
+	Limits map[string]Limits
+}
+
+func (self PoolConfig) Validate() error {
+	if self.CheckInterval.Duration <= 0 {
+		return errors.New("check interval needs to be positive")
+	}
+	if self.MaxFailures < 1 || self.Cooldown.Duration <= 0 {
+		return errors.New("max failures and cooldown need to be positive")
+	}
+	if self.Retries < 0 {
+		return errors.New("retries can't be negative")
+	}
+	if self.Backoff.Duration <= 0 || self.MaxBackoff.Duration < self.Backoff.Duration {
+		return errors.New("backoff needs to be positive and not above the max backoff")
+	}
+	for name, limits := range self.Limits {
+		if err := limits.Validate(); err != nil {
+			return errors.Wrapf(err, "validating limits of endpoint:%v", name)
+		}
+	}
+	return nil
+}
+
+// Endpoint is a node of a pool.
+type Endpoint struct {
+	// Name of the endpoint in the logs and the metrics, without the credentials of the url.
+	Name   string
+	Client Client
+}
+
+// EndpointName returns the host of the node url, so api keys in the path aren't exposed.
+func EndpointName(nodeURL string) string {
+	u, err := url.Parse(nodeURL)
+	if err != nil || u.Host == "" {
+		return "node"
+	}
+	return u.Host
+}
+
+// latencyWeight is the weight of the last call in the moving averages of the latency and the errors.
+const latencyWeight = 0.2
+
+type endpoint struct {
+	Endpoint
+	limiter *limiter
+	mtx     sync.Mutex
+	// Moving averages of the call duration and the error rate.
+	latency   time.Duration
+	errorRate float64
+	failures  int
+	downUntil time.Time
+	head      uint64
+}
+
+func (self *endpoint) observe(d time.Duration, failed bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.latency == 0 {
+		self.latency = d
+	}
+	self.latency = time.Duration(float64(self.latency)*(1-latencyWeight) + float64(d)*latencyWeight)
+	rate := 0.0
+	if failed {
+		rate = 1
+	}
+	self.errorRate = self.errorRate*(1-latencyWeight) + rate*latencyWeight
+}
+
+// score is lower for the faster and more reliable endpoints.
+func (self *endpoint) score() float64 {
+	latency := self.latency
+	if latency == 0 {
+		latency = time.Millisecond
+	}
+	return float64(latency) * (1 + 10*self.errorRate)
+}
+
+// Pool routes the calls of a chain to its healthiest endpoint and fails over to the next ones.
+// Endpoints that fail MaxFailures times in a row, or are rate limited by the node, rest for the Cooldown.
+// Endpoints that lag behind the highest head of the chain or used their daily budget aren't used.
+type Pool struct {
+	logger    log.Logger
+	network   types.Network
+	chain     string
+	cfg       PoolConfig
+	endpoints []*endpoint
+	// served is the highest safe head returned, endpoints behind it aren't used.
+	served uint64
+}
+
+func NewPool(logger log.Logger, network types.Network, cfg PoolConfig, endpoints []Endpoint) (*Pool, error) {
+	chain := string(network)
+	if len(endpoints) == 0 {
+		return nil, errors.Errorf("no %v endpoints", chain)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validating config")
+	}
+	pool := &Pool{logger: log.With(logger, "chain", chain), network: network, chain: chain, cfg: cfg}
+	names := make(map[string]bool)
+	for i, e := range endpoints {
+		limits := cfg.Limits[e.Name]
+		// Endpoints of the same host get a suffix so their metrics don't mix.
+		if names[e.Name] {
+			e.Name += "#" + strconv.Itoa(i)
+		}
+		names[e.Name] = true
+		pool.endpoints = append(pool.endpoints, &endpoint{Endpoint: e, limiter: newLimiter(chain, e.Name, limits)})
+	}
+	return pool, nil
+}
+
+// Run checks the heads of all endpoints every CheckInterval until the context is canceled.
+func (self *Pool) Run(ctx context.Context) {
+	ticker := time.NewTicker(self.cfg.CheckInterval.Duration)
+	defer ticker.Stop()
+	for {
+		self.checkHeads(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (self *Pool) checkHeads(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range self.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			ctx, cncl := context.WithTimeout(ctx, self.cfg.CheckInterval.Duration)
+			defer cncl()
+			if err := e.limiter.take(ctx); err != nil {
+				self.skip(e, err)
+				return
+			}
+			start := time.Now()
+			header, err := e.Client.HeaderByNumber(ctx, nil)
+			self.record(e, start, err)
+			if err != nil {
+				level.Warn(self.logger).Log("msg", "checking endpoint head", "endpoint", e.Name, "err", err)
+				return
+			}
+			e.mtx.Lock()
+			e.head = header.Number.Uint64()
+			e.mtx.Unlock()
+		}(e)
+	}
+	wg.Wait()
+
+	for _, e := range self.endpoints {
+		e.mtx.Lock()
+		metrics.NodeHead.WithLabelValues(self.chain, e.Name).Set(float64(e.head))
+		metrics.NodeScore.WithLabelValues(self.chain, e.Name).Set(e.score())
+		e.mtx.Unlock()
+	}
+	usable := self.usable(time.Now())
+	for _, e := range self.endpoints {
+		up := 0.0
+		for _, u := range usable {
+			if u == e {
+				up = 1
+			}
+		}
+		metrics.NodeUp.WithLabelValues(self.chain, e.Name).Set(up)
+	}
+}
+
+// record updates the health of the endpoint after a call.
+// Permanent errors are returned by the node itself, like a reverted call, so the node works.
+func (self *Pool) record(e *endpoint, start time.Time, err error) {
+	failed := err != nil && !IsPermanent(err)
+	e.observe(time.Since(start), failed)
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if !failed {
+		e.failures = 0
+		return
+	}
+	e.failures++
+	if e.failures >= self.cfg.MaxFailures || isRateLimited(err) {
+		e.downUntil = time.Now().Add(self.cfg.Cooldown.Duration)
+		e.failures = 0
+		level.Warn(self.logger).Log("msg", "endpoint failing, cooling down", "endpoint", e.Name, "until", e.downUntil, "err", err)
+	}
+}
+
+// skip handles an endpoint the limiter didn't allow a call to.
+// An endpoint that used its daily budget isn't used until the next day.
+func (self *Pool) skip(e *endpoint, err error) {
+	if errors.Cause(err) != ErrBudgetExhausted {
+		return
+	}
+	now := time.Now()
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if now.Before(e.downUntil) {
+		return
+	}
+	e.downUntil = nextDay(now)
+	level.Warn(self.logger).Log("msg", "endpoint used its daily budget", "endpoint", e.Name, "until", e.downUntil)
+}
+
+// usable returns the endpoints that aren't cooling down or lagging, healthiest first.
+// When none is usable all endpoints are tried so the calls don't stop completely.
+func (self *Pool) usable(now time.Time) []*endpoint {
+	type state struct {
+		e     *endpoint
+		score float64
+		head  uint64
+		down  bool
+	}
+	states := make([]state, 0, len(self.endpoints))
+	var maxHead uint64
+	for _, e := range self.endpoints {
+		e.mtx.Lock()
+		states = append(states, state{e: e, score: e.score(), head: e.head, down: now.Before(e.downUntil)})
+		if e.head > maxHead {
+			maxHead = e.head
+		}
+		e.mtx.Unlock()
+	}
+	sort.SliceStable(states, func(i, j int) bool { return states[i].score < states[j].score })
+
+	maxLag, ok := self.cfg.MaxLag[self.network]
+	served := atomic.LoadUint64(&self.served)
+	out := make([]*endpoint, 0, len(states))
+	for _, s := range states {
+		if s.down || (ok && s.head+maxLag < maxHead) || s.head < served {
+			continue
+		}
+		out = append(out, s.e)
+	}
+	if len(out) == 0 {
+		for _, s := range states {
+			out = append(out, s.e)
+		}
+	}
+	return out
+}
+
+// safeHead returns the lowest known head of the usable endpoints, zero when no head is known yet.
+// Endpoints behind the returned head aren't used until a head check shows they caught up.
+func (self *Pool) safeHead(ctx context.Context) uint64 {
+	head := self.minHead()
+	if head == 0 {
+		self.checkHeads(ctx)
+		head = self.minHead()
+	}
+	for {
+		served := atomic.LoadUint64(&self.served)
+		if head <= served || atomic.CompareAndSwapUint64(&self.served, served, head) {
+			return head
+		}
+	}
+}
+
+func (self *Pool) minHead() uint64 {
+	var min uint64
+	for _, e := range self.usable(time.Now()) {
+		e.mtx.Lock()
+		head := e.head
+		e.mtx.Unlock()
+		if head > 0 && (min == 0 || head < min) {
+			min = head
+		}
+	}
+	return min
+}
+
+// do calls fn with the healthiest endpoint and the next ones until a call succeeds.
+// When all endpoints fail with transient errors the call is retried after a backoff, up to Retries times.
+func (self *Pool) do(ctx context.Context, method string, fn func(Client) error) error {
+	for attempt := 0; ; attempt++ {
+		err := self.try(ctx, method, fn)
+		if err == nil || IsPermanent(err) || errors.Cause(err) == ErrBudgetExhausted || ctx.Err() != nil || attempt >= self.cfg.Retries {
+			return err
+		}
+		wait := Backoff(self.cfg.Backoff.Duration, self.cfg.MaxBackoff.Duration, attempt)
+		metrics.RPCRetries.WithLabelValues(self.chain, method).Inc()
+		level.Debug(self.logger).Log("msg", "retrying", "method", method, "attempt", attempt+1, "wait", wait, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (self *Pool) try(ctx context.Context, method string, fn func(Client) error) error {
+	var err error
+	for i, e := range self.usable(time.Now()) {
+		if i > 0 {
+			metrics.NodeFailovers.WithLabelValues(self.chain).Inc()
+			level.Debug(self.logger).Log("msg", "failing over", "method", method, "endpoint", e.Name, "err", err)
+		}
+		if err = e.limiter.take(ctx); err != nil {
+			self.skip(e, err)
+			if ctx.Err() != nil {
+				return err
+			}
+			continue
+		}
+		start := time.Now()
+		err = fn(e.Client)
+		self.record(e, start, err)
+		if err == nil || IsPermanent(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (self *Pool) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	var code []byte
+	err := self.do(ctx, "eth_getCode", func(c Client) (err error) {
+		code, err = c.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+func (self *Pool) CallContract(ctx context.Context, call eth.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var out []byte
+	err := self.do(ctx, "eth_call", func(c Client) (err error) {
+		out, err = c.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return out, err
+}
+
+func (self *Pool) FilterLogs(ctx context.Context, query eth.FilterQuery) ([]ethtypes.Log, error) {
+	var logs []ethtypes.Log
+	err := self.do(ctx, "eth_getLogs", func(c Client) (err error) {
+		logs, err = c.FilterLogs(ctx, query)
+		return err
+	})
+	return logs, err
+}
+
+func (self *Pool) SubscribeFilterLogs(ctx context.Context, query eth.FilterQuery, ch chan<- ethtypes.Log) (eth.Subscription, error) {
+	var sub eth.Subscription
+	err := self.do(ctx, "eth_subscribe", func(c Client) (err error) {
+		sub, err = c.SubscribeFilterLogs(ctx, query, ch)
+		return err
+	})
+	return sub, err
+}
+
+// HeaderByNumber with a nil number returns the header of the lowest head of the usable endpoints,
+// so the blocks up to it can be read from any of them even when the next calls fail over.
+func (self *Pool) HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error) {
+	if number == nil {
+		if head := self.safeHead(ctx); head > 0 {
+			number = new(big.Int).SetUint64(head)
+		}
+	}
+	var header *ethtypes.Header
+	err := self.do(ctx, "eth_getBlockByNumber", func(c Client) (err error) {
+		header, err = c.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+func (self *Pool) BlockByNumber(ctx context.Context, number *big.Int) (*ethtypes.Block, error) {
+	var block *ethtypes.Block
+	err := self.do(ctx, "eth_getBlockByNumber_full", func(c Client) (err error) {
+		block, err = c.BlockByNumber(ctx, number)
+		return err
+	})
+	return block, err
+}
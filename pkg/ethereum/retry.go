@@ -0,0 +1,70 @@
+package ethereum
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// rateLimitCode is the json-rpc error code of the providers for a rate limited call.
+const rateLimitCode = -32005
+
+// transientMessages are the json-rpc errors that can succeed later or on another node.
+var transientMessages = []string{"rate limit", "too many requests", "limit exceeded", "header not found", "timeout"}
+
+// IsPermanent tells if a failed call fails the same way when retried, on any node.
+// These are the errors answered by the node itself, like a reverted call or a missing block.
+// Rate limits, http and network errors are transient.
+func IsPermanent(err error) bool {
+	cause := errors.Cause(err)
+	if cause == eth.NotFound {
+		return true
+	}
+	rpcErr, ok := cause.(rpc.Error)
+	if !ok {
+		return false
+	}
+	if rpcErr.ErrorCode() == rateLimitCode {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	for _, m := range transientMessages {
+		if strings.Contains(msg, m) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRateLimited tells if the node refused the call because of its rate limit.
+func isRateLimited(err error) bool {
+	switch cause := errors.Cause(err).(type) {
+	case rpc.HTTPError:
+		return cause.StatusCode == http.StatusTooManyRequests
+	case rpc.Error:
+		return cause.ErrorCode() == rateLimitCode
+	}
+	return false
+}
+
+// Backoff returns the wait before the retry after the given number of failed attempts.
+// It doubles with every attempt from base up to max, with a random jitter of up to half of it
+// so the retries of many callers don't hit the node together.
+func Backoff(base, max time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	if d <= 1 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
@@ -0,0 +1,227 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package export streams the stored transfers, tvl snapshots and prices
+// as csv or json lines with a stable column schema.
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	KindTransfers = "transfers"
+	KindTVL       = "tvl"
+	KindPrices    = "prices"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Columns of every kind, new columns are only appended to keep the schema stable.
+var Columns = map[string][]string{
+	KindTransfers: {"time", "bridge", "side", "symbol", "token", "from", "to", "hash", "deposit_id", "block_number", "amount", "amount_usd", "fee"},
+	KindTVL:       {"time", "network", "symbol", "token", "tvl", "tvl_usd"},
+	KindPrices:    {"time", "symbol", "source", "price", "age_seconds"},
+}
+
+// ContentType returns the mime type of the format.
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// Validate checks that the kind and the format are supported.
+func Validate(kind, format string) error {
+	if _, ok := Columns[kind]; !ok {
+		return errors.Errorf("invalid kind:%v, supported are transfers, tvl and prices", kind)
+	}
+	if format != FormatCSV && format != FormatNDJSON {
+		return errors.Errorf("invalid format:%v, supported are csv and ndjson", format)
+	}
+	return nil
+}
+
+// ValidateQuery checks that the kind has the tags of the query filters,
+// a filter on a missing tag would silently export nothing.
+func ValidateQuery(kind string, q types.Query) error {
+	for _, f := range []struct {
+		name  string
+		value string
+		kinds []string
+	}{
+		{"bridge", string(q.Bridge), []string{KindTransfers}},
+		{"side", string(q.Side), []string{KindTransfers}},
+		{"network", string(q.Network), []string{KindTVL}},
+	} {
+		if f.value == "" {
+			continue
+		}
+		supported := false
+		for _, k := range f.kinds {
+			supported = supported || k == kind
+		}
+		if !supported {
+			return errors.Errorf("%v can't be filtered by %v", kind, f.name)
+		}
+	}
+	return nil
+}
+
+// Write streams the rows of the kind that match the query to the writer
+// and returns the number of written rows.
+// Rows are written as they are read from the store so memory use doesn't grow with the range.
+func Write(ctx context.Context, store *bridge.Store, w io.Writer, kind, format string, q types.Query) (int, error) {
+	if err := Validate(kind, format); err != nil {
+		return 0, err
+	}
+	if err := ValidateQuery(kind, q); err != nil {
+		return 0, err
+	}
+	buf := bufio.NewWriter(w)
+	rw := newRowWriter(buf, format, Columns[kind])
+	if err := rw.header(); err != nil {
+		return 0, errors.Wrap(err, "writing header")
+	}
+
+	var (
+		rows int
+		err  error
+	)
+	write := func(values ...interface{}) error {
+		rows++
+		return rw.row(values)
+	}
+	switch kind {
+	case KindTransfers:
+		err = store.EachTransfer(ctx, q, func(tx types.Transaction) error {
+			return write(
+				time.Unix(int64(tx.Timestamp), 0).UTC(),
+				string(tx.Bridge),
+				string(tx.BridgeSide),
+				tx.Symbol,
+				tx.Token,
+				tx.From,
+				tx.To,
+				tx.Hash,
+				tx.DepositID,
+				tx.BlockNo,
+				tx.Amount,
+				tx.AmountUSD,
+				tx.Fee,
+			)
+		})
+	case KindTVL:
+		err = store.EachTVL(ctx, q, func(p types.TVLPoint) error {
+			return write(p.Time.UTC(), string(p.Network), p.Symbol, p.Token, p.Value, p.ValueUSD)
+		})
+	case KindPrices:
+		err = store.EachPrice(ctx, q, func(p types.PricePoint) error {
+			return write(p.Time.UTC(), p.Symbol, p.Source, p.Value, p.Age.Seconds())
+		})
+	}
+	if err != nil {
+		return rows, errors.Wrapf(err, "exporting %v", kind)
+	}
+	if err := rw.flush(); err != nil {
+		return rows, errors.Wrap(err, "flushing rows")
+	}
+	return rows, buf.Flush()
+}
+
+type rowWriter struct {
+	format  string
+	columns []string
+	w       *bufio.Writer
+	csv     *csv.Writer
+	record  []string
+}
+
+func newRowWriter(w *bufio.Writer, format string, columns []string) *rowWriter {
+	rw := &rowWriter{
+		format:  format,
+		columns: columns,
+		w:       w,
+		record:  make([]string, len(columns)),
+	}
+	if format == FormatCSV {
+		rw.csv = csv.NewWriter(w)
+	}
+	return rw
+}
+
+// header writes the column names, json lines carry them in every row.
+func (self *rowWriter) header() error {
+	if self.csv == nil {
+		return nil
+	}
+	return self.csv.Write(self.columns)
+}
+
+func (self *rowWriter) row(values []interface{}) error {
+	if self.csv != nil {
+		for i, v := range values {
+			self.record[i] = formatValue(v)
+		}
+		return self.csv.Write(self.record)
+	}
+
+	// Written by hand to keep the column order of the schema.
+	self.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			self.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(self.columns[i])
+		self.w.Write(key)
+		self.w.WriteByte(':')
+		if t, ok := v.(time.Time); ok {
+			v = t.Format(time.RFC3339)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return errors.Wrapf(err, "encoding column:%v", self.columns[i])
+		}
+		self.w.Write(b)
+	}
+	self.w.WriteByte('}')
+	_, err := self.w.WriteString("\n")
+	return err
+}
+
+func (self *rowWriter) flush() error {
+	if self.csv == nil {
+		return nil
+	}
+	self.csv.Flush()
+	return self.csv.Error()
+}
+
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case uint64:
+		return strconv.FormatUint(value, 10)
+	case time.Time:
+		return value.Format(time.RFC3339)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package format
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// IoTeXAddressPrefix is the bech32 human readable part of the IoTeX addresses.
+const IoTeXAddressPrefix = "io"
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// ParseAddress parses a 0x hex or an io1 bech32 address.
+// Both are encodings of the same 20 bytes.
+func ParseAddress(v string) (common.Address, error) {
+	if common.IsHexAddress(v) {
+		return common.HexToAddress(v), nil
+	}
+	hrp, data, err := bech32Decode(v)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "invalid address")
+	}
+	if hrp != IoTeXAddressPrefix {
+		return common.Address{}, errors.Errorf("invalid address prefix:%v", hrp)
+	}
+	b, err := convertBits(data, 5, 8, false)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "invalid address")
+	}
+	if len(b) != common.AddressLength {
+		return common.Address{}, errors.Errorf("invalid address length:%v", len(b))
+	}
+	return common.BytesToAddress(b), nil
+}
+
+// IoTeXAddress returns the io1 encoding of the address.
+func IoTeXAddress(addr common.Address) string {
+	// Converting 8 to 5 bits with padding never fails.
+	data, _ := convertBits(addr.Bytes(), 8, 5, true)
+	return bech32Encode(IoTeXAddressPrefix, data)
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for _, c := range hrp {
+		out = append(out, byte(c>>5))
+	}
+	out = append(out, 0)
+	for _, c := range hrp {
+		out = append(out, byte(c&31))
+	}
+	return out
+}
+
+func bech32Encode(hrp string, data []byte) string {
+	values := append(bech32HrpExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ 1
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range data {
+		sb.WriteByte(bech32Charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return sb.String()
+}
+
+// bech32Decode returns the human readable part and the 5 bit data without the checksum.
+func bech32Decode(v string) (string, []byte, error) {
+	if len(v) > 90 {
+		return "", nil, errors.New("too long")
+	}
+	if strings.ToLower(v) != v && strings.ToUpper(v) != v {
+		return "", nil, errors.New("mixed case")
+	}
+	v = strings.ToLower(v)
+	pos := strings.LastIndexByte(v, '1')
+	if pos < 1 || pos+7 > len(v) {
+		return "", nil, errors.New("invalid separator position")
+	}
+	hrp := v[:pos]
+	data := make([]byte, 0, len(v)-pos-1)
+	for _, c := range v[pos+1:] {
+		d := strings.IndexRune(bech32Charset, c)
+		if d == -1 {
+			return "", nil, errors.Errorf("invalid character:%q", c)
+		}
+		data = append(data, byte(d))
+	}
+	if bech32Polymod(append(bech32HrpExpand(hrp), data...)) != 1 {
+		return "", nil, errors.New("invalid checksum")
+	}
+	return hrp, data[:len(data)-6], nil
+}
+
+// convertBits regroups the data from groups of fromBits to groups of toBits.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var (
+		acc  uint32
+		bits uint
+		out  []byte
+		max  = uint32(1)<<toBits - 1
+	)
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, errors.Errorf("invalid data value:%v", v)
+		}
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&max))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&max))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&max != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	return out, nil
+}
@@ -0,0 +1,251 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/logging"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	"github.com/pkg/errors"
+)
+
+const ComponentName = "health"
+
+type Config struct {
+	LogLevel string
+	// Timeout of every check.
+	Timeout format.Duration
+	// CacheTTL is how long a readiness report is reused,
+	// so frequent probes don't load the nodes and the db.
+	CacheTTL format.Duration
+	// MaxLag is the max number of blocks a tracker can be behind the head
+	// of the network it watches before the service is unready, Map: network -> blocks.
+	MaxLag map[types.Network]uint64
+}
+
+// HeadReader returns the latest block header of a chain.
+type HeadReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error)
+}
+
+// Tracker is identified by the network it watches and its peer,
+// the same as its last checked block in the store.
+type Tracker struct {
+	Network types.Network
+	Peer    types.Network
+}
+
+// Networks are all the networks of the service.
+var Networks = []types.Network{types.NetEthereum, types.NetIoTeX, types.NetPolygon, types.NetBsc}
+
+// Trackers are all the tx trackers of the service.
+var Trackers = []Tracker{
+	{types.NetEthereum, types.NetIoTeX},
+	{types.NetIoTeX, types.NetEthereum},
+	{types.NetPolygon, types.NetIoTeX},
+	{types.NetIoTeX, types.NetPolygon},
+	{types.NetBsc, types.NetIoTeX},
+	{types.NetIoTeX, types.NetBsc},
+}
+
+type Check struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency"`
+}
+
+type ChainStatus struct {
+	Check
+	Network types.Network `json:"network"`
+	Head    uint64        `json:"head,omitempty"`
+}
+
+type TrackerStatus struct {
+	Network     types.Network `json:"network"`
+	Peer        types.Network `json:"peer"`
+	OK          bool          `json:"ok"`
+	Error       string        `json:"error,omitempty"`
+	LastChecked uint64        `json:"lastChecked,omitempty"`
+	Head        uint64        `json:"head,omitempty"`
+	Lag         uint64        `json:"lag"`
+	MaxLag      uint64        `json:"maxLag"`
+}
+
+type Report struct {
+	Ready    bool            `json:"ready"`
+	Time     time.Time       `json:"time"`
+	Storage  Check           `json:"storage"`
+	Chains   []ChainStatus   `json:"chains"`
+	Trackers []TrackerStatus `json:"trackers"`
+}
+
+// Health reports whether the service and its dependencies work
+// and whether the trackers keep up with the chains.
+type Health struct {
+	logger log.Logger
+	cfg    Config
+	tsdb   influxdb2.Client
+	store  *bridge.Store
+	chains map[types.Network]HeadReader
+	// trackers that are started, only they are checked.
+	trackers []Tracker
+
+	mtx  sync.Mutex
+	last *Report
+}
+
+func New(logger log.Logger, cfg Config, tsdb influxdb2.Client, store *bridge.Store, chains map[types.Network]HeadReader, trackers []Tracker) (*Health, error) {
+	filterLog, err := logging.ApplyFilter(cfg.LogLevel, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "apply filter logger")
+	}
+	return &Health{
+		logger:   log.With(filterLog, "component", ComponentName),
+		cfg:      cfg,
+		tsdb:     tsdb,
+		store:    store,
+		chains:   chains,
+		trackers: trackers,
+	}, nil
+}
+
+// Check runs all checks, or returns the last report when it is newer than the cache ttl.
+func (self *Health) Check(ctx context.Context) Report {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if self.last != nil && time.Since(self.last.Time) < self.cfg.CacheTTL.Duration {
+		return *self.last
+	}
+
+	report := Report{Ready: true, Time: time.Now()}
+	report.Storage = self.checkStorage(ctx)
+	report.Ready = report.Storage.OK
+
+	heads := make(map[types.Network]uint64)
+	networks := make([]string, 0, len(self.chains))
+	for network := range self.chains {
+		networks = append(networks, string(network))
+	}
+	sort.Strings(networks)
+	for _, network := range networks {
+		status := self.checkChain(ctx, types.Network(network))
+		if status.OK {
+			heads[status.Network] = status.Head
+		}
+		report.Ready = report.Ready && status.OK
+		report.Chains = append(report.Chains, status)
+	}
+
+	for _, tracker := range self.trackers {
+		status := self.checkTracker(tracker, heads)
+		report.Ready = report.Ready && status.OK
+		report.Trackers = append(report.Trackers, status)
+	}
+	if !report.Ready {
+		level.Warn(self.logger).Log("msg", "service not ready", "report", fmtReport(report))
+	}
+	self.last = &report
+	return report
+}
+
+func (self *Health) checkStorage(ctx context.Context) Check {
+	ctx, cncl := context.WithTimeout(ctx, self.cfg.Timeout.Duration)
+	defer cncl()
+	start := time.Now()
+	check := Check{OK: true}
+	h, err := self.tsdb.Health(ctx)
+	if err != nil {
+		check.OK, check.Error = false, err.Error()
+	} else if h.Status != domain.HealthCheckStatusPass {
+		check.OK = false
+		if h.Message != nil {
+			check.Error = *h.Message
+		}
+	}
+	check.Latency = time.Since(start).String()
+	return check
+}
+
+func (self *Health) checkChain(ctx context.Context, network types.Network) ChainStatus {
+	ctx, cncl := context.WithTimeout(ctx, self.cfg.Timeout.Duration)
+	defer cncl()
+	start := time.Now()
+	status := ChainStatus{Network: network, Check: Check{OK: true}}
+	header, err := self.chains[network].HeaderByNumber(ctx, nil)
+	if err != nil {
+		status.OK, status.Error = false, err.Error()
+	} else {
+		status.Head = header.Number.Uint64()
+	}
+	status.Latency = time.Since(start).String()
+	return status
+}
+
+func (self *Health) checkTracker(tracker Tracker, heads map[types.Network]uint64) TrackerStatus {
+	status := TrackerStatus{
+		Network: tracker.Network,
+		Peer:    tracker.Peer,
+		MaxLag:  self.cfg.MaxLag[tracker.Network],
+	}
+	head, ok := heads[tracker.Network]
+	if !ok {
+		status.Error = "unknown head block"
+		return status
+	}
+	status.Head = head
+	last, err := self.store.LastCheckedBlockNo(tracker.Network, tracker.Peer)
+	if err != nil || last == nil {
+		status.Error = "no checked block"
+		if err != nil {
+			status.Error = errors.Wrap(err, "getting last checked block").Error()
+		}
+		return status
+	}
+	status.LastChecked = last.Uint64()
+	if head > status.LastChecked {
+		status.Lag = head - status.LastChecked
+	}
+	status.OK = status.MaxLag == 0 || status.Lag <= status.MaxLag
+	if !status.OK {
+		status.Error = "tracker is behind the head"
+	}
+	return status
+}
+
+func fmtReport(r Report) string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// ServeLive answers as long as the process can serve requests.
+func (self *Health) ServeLive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+// ServeReady runs the checks and answers with 503 when any of them fails.
+func (self *Health) ServeReady(w http.ResponseWriter, r *http.Request) {
+	report := self.Check(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		level.Error(self.logger).Log("msg", "writing readiness report", "err", err)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+// Package metrics holds the prometheus metrics of the indexer internals.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "iotube"
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+var (
+	RPCCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_calls_total",
+		Help:      "The total number of node rpc calls by chain, method and outcome.",
+	}, []string{"chain", "method", "outcome"})
+
+	RPCDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_call_duration_seconds",
+		Help:      "The duration of the node rpc calls by chain and method.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"chain", "method"})
+
+	NodeHead = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_head_block",
+		Help:      "The head block of every node endpoint by chain.",
+	}, []string{"chain", "endpoint"})
+
+	NodeScore = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_score",
+		Help:      "The health score of every node endpoint by chain, lower is healthier.",
+	}, []string{"chain", "endpoint"})
+
+	NodeUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_up",
+		Help:      "Whether every node endpoint is used, it isn't while cooling down or lagging.",
+	}, []string{"chain", "endpoint"})
+
+	NodeFailovers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "node_failovers_total",
+		Help:      "The total number of calls retried on another node endpoint by chain.",
+	}, []string{"chain"})
+
+	RPCRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_retries_total",
+		Help:      "The total number of rpc calls retried after a backoff by chain and method.",
+	}, []string{"chain", "method"})
+
+	NodeBudgetUsed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_budget_used",
+		Help:      "The calls made to every node endpoint in the current UTC day.",
+	}, []string{"chain", "endpoint"})
+
+	NodeBudgetLimit = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_budget_limit",
+		Help:      "The daily call budget of every node endpoint, zero when unlimited.",
+	}, []string{"chain", "endpoint"})
+
+	NodeRateLimitWait = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "node_rate_limit_wait_seconds_total",
+		Help:      "The total time the calls waited for the rate limit of every node endpoint.",
+	}, []string{"chain", "endpoint"})
+
+	BlocksScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "blocks_scanned_total",
+		Help:      "The total number of blocks scanned by every tracker.",
+	}, []string{"tracker"})
+
+	EventsDecoded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_decoded_total",
+		Help:      "The total number of bridge events decoded by every tracker.",
+	}, []string{"tracker"})
+
+	LastCheckedBlock = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tracker_last_checked_block",
+		Help:      "The last block committed by every tracker.",
+	}, []string{"tracker"})
+
+	HeadBlock = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tracker_head_block",
+		Help:      "The head block of the chain watched by every tracker.",
+	}, []string{"tracker"})
+
+	StoreWriteDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "store_write_duration_seconds",
+		Help:      "The duration of the store writes by measurement.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"measurement"})
+
+	StoreWriteFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "store_write_failures_total",
+		Help:      "The total number of failed store writes by measurement.",
+	}, []string{"measurement"})
+
+	PriceFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "price_fetches_total",
+		Help:      "The total number of price requests by provider and outcome.",
+	}, []string{"provider", "outcome"})
+
+	PricesQuarantined = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "prices_quarantined_total",
+		Help:      "The total number of prices that failed validation by symbol.",
+	}, []string{"symbol"})
+
+	AlertDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alert_deliveries_total",
+		Help:      "The total number of alert deliveries by notifier and outcome.",
+	}, []string{"notifier", "outcome"})
+
+	RuleFiring = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "alert_rule_firing",
+		Help:      "Whether every alert rule is firing.",
+	}, []string{"rule"})
+)
+
+// Outcome returns the outcome label of the error.
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
+
+// ObserveStoreWrite records the duration and the failure of a store write started at start.
+func ObserveStoreWrite(measurement string, start time.Time, err error) {
+	StoreWriteDuration.WithLabelValues(measurement).Observe(time.Since(start).Seconds())
+	if err != nil {
+		StoreWriteFailures.WithLabelValues(measurement).Inc()
+	}
+}
+
+// Handler serves the metrics of the default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,217 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/openapi/swagger/models"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/openapi/swagger/restapi"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/openapi/swagger/restapi/operations"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/openapi/swagger/restapi/operations/data"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/pkg/errors"
+)
+
+const ComponentName = "polydefi"
+
+// BasePath of the polydefi api, from the swagger spec.
+const BasePath = "/v1/"
+
+const (
+	defiName     = "ioTube"
+	defiCategory = "Bridge"
+	// maxChartDays limits the chart range to keep the queries cheap.
+	maxChartDays = 365
+	// holdersTTL is how long the holder counts are reused,
+	// they scan the whole history so they aren't queried on every request.
+	holdersTTL = 10 * time.Minute
+)
+
+// Map: bridge -> the network where its tokens are locked.
+var bridges = map[types.Bridge]types.Network{
+	types.EthereumIoteX: types.NetEthereum,
+	types.BscIoteX:      types.NetBsc,
+	types.PolygonIoteX:  types.NetPolygon,
+}
+
+// Polydefi serves the polydefi api operations from the bridge store.
+type Polydefi struct {
+	logger log.Logger
+	store  *bridge.Store
+
+	holdersMtx sync.Mutex
+	holders    map[types.Bridge]holderCounts
+}
+
+// holderCounts of a bridge now and 24h before, counted at the given time.
+type holderCounts struct {
+	now, dayAgo int64
+	at          time.Time
+}
+
+// NewHandler returns the http handler of the polydefi api.
+func NewHandler(logger log.Logger, store *bridge.Store) (http.Handler, error) {
+	spec, err := loads.Analyzed(restapi.SwaggerJSON, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "loading swagger spec")
+	}
+	logger = log.With(logger, "component", ComponentName)
+	self := &Polydefi{
+		logger:  logger,
+		store:   store,
+		holders: make(map[types.Bridge]holderCounts),
+	}
+
+	api := operations.NewPolydefiAPI(spec)
+	api.Logger = func(format string, args ...interface{}) {
+		level.Debug(logger).Log("msg", fmt.Sprintf(format, args...))
+	}
+	api.DataGetAllDataHandler = data.GetAllDataHandlerFunc(self.getAllData)
+	api.DataGetChartDataHandler = data.GetChartDataHandlerFunc(self.getChartData)
+	return restapi.Handler(api), nil
+}
+
+func (self *Polydefi) getAllData(params data.GetAllDataParams) middleware.Responder {
+	ctx := params.HTTPRequest.Context()
+	now := time.Now()
+	dayAgo := now.Add(-24 * time.Hour)
+
+	all := make(models.AllData, 0, len(bridges))
+	for _, b := range sortedBridges() {
+		network := bridges[b]
+		locked, tokens, lastUpdated, err := self.store.LockedUSD(ctx, network, now)
+		if err != nil {
+			return self.notFound(errors.Wrapf(err, "getting locked usd bridge:%v", b))
+		}
+		lockedDayAgo, _, _, err := self.store.LockedUSD(ctx, network, dayAgo)
+		if err != nil {
+			return self.notFound(errors.Wrapf(err, "getting locked usd 24h ago bridge:%v", b))
+		}
+		volume, err := self.store.VolumeUSD(ctx, b, dayAgo, now)
+		if err != nil {
+			return self.notFound(errors.Wrapf(err, "getting volume bridge:%v", b))
+		}
+		holders, err := self.bridgeHolders(ctx, b, now)
+		if err != nil {
+			return self.notFound(err)
+		}
+
+		var change float64
+		if lockedDayAgo != 0 {
+			change = (locked - lockedDayAgo) / lockedDayAgo * 100
+		}
+		all = append(all, &models.DefiData{
+			Name:                defiName,
+			Chain:               string(network),
+			Category:            defiCategory,
+			Volume:              int64(volume),
+			LockedUsd:           int64(locked),
+			TvlPercentChange24h: change,
+			ContractNum:         int64(tokens),
+			LastUpdated:         lastUpdated.Unix(),
+			Holders:             holders.now,
+			HoldersChange24hNum: holders.now - holders.dayAgo,
+		})
+	}
+	return data.NewGetAllDataOK().WithPayload(all)
+}
+
+// bridgeHolders returns the holders of the bridge, counted again when older than holdersTTL.
+// The lock is held while counting so concurrent requests wait for a single count.
+func (self *Polydefi) bridgeHolders(ctx context.Context, b types.Bridge, now time.Time) (holderCounts, error) {
+	self.holdersMtx.Lock()
+	defer self.holdersMtx.Unlock()
+	if h, ok := self.holders[b]; ok && now.Sub(h.at) < holdersTTL {
+		return h, nil
+	}
+	h := holderCounts{at: now}
+	var err error
+	h.now, err = self.store.Holders(ctx, b, now)
+	if err != nil {
+		return holderCounts{}, errors.Wrapf(err, "getting holders bridge:%v", b)
+	}
+	h.dayAgo, err = self.store.Holders(ctx, b, now.Add(-24*time.Hour))
+	if err != nil {
+		return holderCounts{}, errors.Wrapf(err, "getting holders 24h ago bridge:%v", b)
+	}
+	self.holders[b] = h
+	return h, nil
+}
+
+func (self *Polydefi) getChartData(params data.GetChartDataParams) middleware.Responder {
+	if params.Days <= 0 || params.Days > maxChartDays {
+		return data.NewGetChartDataNotFound().WithPayload(&models.APIResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("days needs to be between 1 and %v", maxChartDays),
+		})
+	}
+	ctx := params.HTTPRequest.Context()
+	now := time.Now()
+	q := types.Query{
+		From:     now.Add(-time.Duration(params.Days) * 24 * time.Hour),
+		To:       now,
+		Interval: 24 * time.Hour,
+	}
+
+	// Map: day -> totals of all bridges.
+	points := make(map[time.Time]*models.ChartPoint)
+	point := func(t time.Time) *models.ChartPoint {
+		p, ok := points[t]
+		if !ok {
+			p = &models.ChartPoint{Time: strfmt.DateTime(t)}
+			points[t] = p
+		}
+		return p
+	}
+	tvl, err := self.store.TVL(ctx, q)
+	if err != nil {
+		return self.chartNotFound(errors.Wrap(err, "getting tvl"))
+	}
+	for _, p := range tvl {
+		point(p.Time).LockedUsd += p.ValueUSD
+	}
+	volume, err := self.store.Volume(ctx, q)
+	if err != nil {
+		return self.chartNotFound(errors.Wrap(err, "getting volume"))
+	}
+	for _, p := range volume {
+		point(p.Time).VolumeUsd += p.AmountUSD
+	}
+
+	chart := make(models.ChartData, 0, len(points))
+	for _, p := range points {
+		chart = append(chart, p)
+	}
+	sort.Slice(chart, func(i, j int) bool { return time.Time(chart[i].Time).Before(time.Time(chart[j].Time)) })
+	return data.NewGetChartDataOK().WithPayload(chart)
+}
+
+func (self *Polydefi) notFound(err error) middleware.Responder {
+	level.Error(self.logger).Log("msg", "getting all data", "err", err)
+	return data.NewGetAllDataNotFound().WithPayload(&models.APIResponse{Status: "error", Message: err.Error()})
+}
+
+func (self *Polydefi) chartNotFound(err error) middleware.Responder {
+	level.Error(self.logger).Log("msg", "getting chart data", "err", err)
+	return data.NewGetChartDataNotFound().WithPayload(&models.APIResponse{Status: "error", Message: err.Error()})
+}
+
+// sortedBridges returns the bridges in a stable order.
+func sortedBridges() []types.Bridge {
+	out := make([]types.Bridge, 0, len(bridges))
+	for b := range bridges {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
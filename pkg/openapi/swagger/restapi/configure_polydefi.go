@@ -57,6 +57,12 @@ func configureAPI(api *operations.PolydefiAPI) http.Handler {
 	return setupGlobalMiddleware(api.Serve(setupMiddlewares))
 }
 
+// Handler configures the api and returns its http handler,
+// so it can be served by an existing http server.
+func Handler(api *operations.PolydefiAPI) http.Handler {
+	return configureAPI(api)
+}
+
 // The TLS configuration before HTTPS server starts.
 func configureTLS(tlsConfig *tls.Config) {
 	// Make all necessary changes to the TLS configuration here.
@@ -122,7 +122,31 @@ func init() {
       }
     },
     "ChartData": {
-      "type": "object"
+      "type": "array",
+      "items": {
+        "$ref": "#/definitions/ChartPoint"
+      }
+    },
+    "ChartPoint": {
+      "type": "object",
+      "properties": {
+        "lockedUsd": {
+          "description": "Locked Usd",
+          "type": "number",
+          "x-omitempty": false
+        },
+        "time": {
+          "description": "Day",
+          "type": "string",
+          "format": "date-time",
+          "x-omitempty": false
+        },
+        "volumeUsd": {
+          "description": "Volume Usd",
+          "type": "number",
+          "x-omitempty": false
+        }
+      }
     },
     "DefiData": {
       "type": "object",
@@ -316,7 +340,31 @@ func init() {
       }
     },
     "ChartData": {
-      "type": "object"
+      "type": "array",
+      "items": {
+        "$ref": "#/definitions/ChartPoint"
+      }
+    },
+    "ChartPoint": {
+      "type": "object",
+      "properties": {
+        "lockedUsd": {
+          "description": "Locked Usd",
+          "type": "number",
+          "x-omitempty": false
+        },
+        "time": {
+          "description": "Day",
+          "type": "string",
+          "format": "date-time",
+          "x-omitempty": false
+        },
+        "volumeUsd": {
+          "description": "Volume Usd",
+          "type": "number",
+          "x-omitempty": false
+        }
+      }
     },
     "DefiData": {
       "type": "object",
@@ -0,0 +1,193 @@
+package price
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// resolveRetry is how long to wait before trying again to resolve the price id of a token
+// that the provider doesn't know, and the max wait after other failures.
+const resolveRetry = 24 * time.Hour
+
+// ErrNotFound is returned when a provider doesn't know the token, retrying won't change it.
+var ErrNotFound = errors.New("not found")
+
+// TokenConfig maps a token to its price id.
+type TokenConfig struct {
+	Network types.Network
+	Address string
+	// ID of the token in the price apis, for example the coingecko coin id.
+	ID string
+}
+
+// Asset is a token along with its price id.
+type Asset struct {
+	types.Token
+	ID string
+}
+
+// IDResolver is a price provider that can look up the price id of a token by its contract address.
+// It fails with ErrNotFound when it doesn't know the token.
+type IDResolver interface {
+	ResolveID(ctx context.Context, network types.Network, address string) (string, error)
+}
+
+// SymbolPricer is a price provider that prices the assets by their symbol, so they don't need a price id.
+type SymbolPricer interface {
+	HasSymbol(symbol string) bool
+}
+
+func tokenKey(network types.Network, address string) string {
+	return string(network) + ":" + strings.ToLower(address)
+}
+
+// Assets returns the priced assets for all tokens in the store.
+// When symbols are given only the tokens with one of these symbols are returned.
+// Every symbol is returned once, since prices are recorded per symbol, with a price id when any of its tokens has one.
+// Tokens without a price id are still returned when a provider prices their symbol.
+func (self *PriceTracker) Assets(symbols ...string) ([]Asset, error) {
+	tokens, err := self.store.GetAllTokens()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting tokens")
+	}
+	wanted := make(map[string]bool)
+	for _, symbol := range symbols {
+		wanted[strings.ToLower(symbol)] = true
+	}
+
+	assets := make([]Asset, 0, len(tokens))
+	// Map: symbol -> index of its asset.
+	seen := make(map[string]int)
+	unpriced := make([]string, 0)
+	for _, token := range tokens {
+		symbol := strings.ToLower(token.Symbol)
+		if len(wanted) > 0 && !wanted[symbol] {
+			continue
+		}
+		i, ok := seen[symbol]
+		if ok && assets[i].ID != "" {
+			continue
+		}
+		id, err := self.resolve(token)
+		if err != nil {
+			level.Debug(self.logger).Log("msg", "resolving price id", "network", token.Network, "token", token.Address, "symbol", token.Symbol, "err", err)
+		}
+		switch {
+		case ok && id != "":
+			assets[i] = Asset{Token: token, ID: id}
+		case ok:
+		case id != "" || self.pricedBySymbol(token.Symbol):
+			seen[symbol] = len(assets)
+			assets = append(assets, Asset{Token: token, ID: id})
+		default:
+			unpriced = append(unpriced, string(token.Network)+":"+token.Symbol+":"+token.Address)
+		}
+	}
+
+	self.mtx.Lock()
+	self.unpriced = unpriced
+	self.mtx.Unlock()
+	if len(unpriced) > 0 {
+		level.Warn(self.logger).Log("msg", "tokens without a price id, add them to the price config", "tokens", strings.Join(unpriced, ","))
+	}
+	return assets, nil
+}
+
+// Unpriced returns the tokens from the last lookup that have no price id, as network:symbol:address.
+func (self *PriceTracker) Unpriced() []string {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	out := make([]string, len(self.unpriced))
+	copy(out, self.unpriced)
+	return out
+}
+
+// pricedBySymbol is whether any provider prices the symbol without a price id.
+func (self *PriceTracker) pricedBySymbol(symbol string) bool {
+	for _, p := range self.providers {
+		if sp, ok := p.(SymbolPricer); ok && sp.HasSymbol(symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the price id of the token from the config,
+// or looks it up by the token address with the first provider that supports it.
+// A token the provider doesn't know is looked up again after resolveRetry,
+// other failures on the next update and then with a doubling wait.
+func (self *PriceTracker) resolve(token types.Token) (string, error) {
+	key := tokenKey(token.Network, token.Address)
+	if id, ok := self.ids[key]; ok {
+		return id, nil
+	}
+
+	self.mtx.Lock()
+	r, ok := self.resolved[key]
+	self.mtx.Unlock()
+	if ok && (r.id != "" || time.Now().Before(r.retryAt)) {
+		return r.id, r.err
+	}
+
+	var resolver IDResolver
+	for _, p := range self.providers {
+		if res, ok := p.(IDResolver); ok {
+			resolver = res
+			break
+		}
+	}
+	if resolver == nil {
+		return "", errors.New("none of the configured providers can resolve price ids")
+	}
+	ctx, cncl := context.WithTimeout(self.ctx, self.cfg.ResolveTimeout.Duration)
+	defer cncl()
+	id, err := resolver.ResolveID(ctx, token.Network, token.Address)
+
+	// Remember failures too so unknown tokens are not looked up on every update.
+	r = resolvedID{id: id, err: err}
+	if err != nil {
+		r.id = ""
+		r.retryAt = time.Now().Add(resolveRetry)
+		if errors.Cause(err) != ErrNotFound {
+			r.failures++
+			r.retryAt = time.Now().Add(resolveBackoff(self.cfg.Interval.Duration, r.failures))
+		}
+	}
+	self.mtx.Lock()
+	self.resolved[key] = r
+	self.mtx.Unlock()
+	if err != nil {
+		return "", err
+	}
+	level.Info(self.logger).Log("msg", "price id resolved", "network", token.Network, "token", token.Address, "symbol", token.Symbol, "id", id)
+	return id, nil
+}
+
+type resolvedID struct {
+	id  string
+	err error
+	// failures in a row that aren't a not found.
+	failures int
+	retryAt  time.Time
+}
+
+// resolveBackoff is the wait before resolving again after the failures in a row,
+// none for the first so it is retried on the next update, then doubling from the update interval.
+func resolveBackoff(interval time.Duration, failures int) time.Duration {
+	if failures <= 1 {
+		return 0
+	}
+	backoff := interval
+	for i := 2; i < failures && backoff < resolveRetry; i++ {
+		backoff *= 2
+	}
+	if backoff > resolveRetry {
+		backoff = resolveRetry
+	}
+	return backoff
+}
@@ -0,0 +1,43 @@
+package price
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrStalePrice is returned when the cached price is older than the requested max age.
+var ErrStalePrice = errors.New("stale price")
+
+// Cache keeps the latest quote of every symbol in memory.
+type Cache struct {
+	mtx    sync.RWMutex
+	quotes map[string]Quote
+}
+
+func NewCache() *Cache {
+	return &Cache{quotes: make(map[string]Quote)}
+}
+
+func (self *Cache) Set(quote Quote) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.quotes[strings.ToLower(quote.Symbol)] = quote
+}
+
+// Get returns the latest quote of the symbol.
+// It fails with ErrStalePrice when the quote is older than maxAge, zero maxAge accepts any age.
+func (self *Cache) Get(symbol string, maxAge time.Duration) (Quote, error) {
+	self.mtx.RLock()
+	quote, ok := self.quotes[strings.ToLower(symbol)]
+	self.mtx.RUnlock()
+	if !ok {
+		return Quote{}, errors.Errorf("no price for symbol:%v", symbol)
+	}
+	if maxAge > 0 && quote.Age() > maxAge {
+		return quote, errors.Wrapf(ErrStalePrice, "symbol:%v age:%v max age:%v", symbol, quote.Age().Round(time.Second), maxAge)
+	}
+	return quote, nil
+}
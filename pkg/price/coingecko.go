@@ -0,0 +1,202 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/pkg/errors"
+)
+
+var CoinGeckoAPI = "https://api.coingecko.com/api/v3/simple/price?ids=%v&vs_currencies=usd&include_last_updated_at=true"
+var CoinGeckoContractAPI = "https://api.coingecko.com/api/v3/coins/%v/contract/%v"
+var CoinGeckoHistoryAPI = "https://api.coingecko.com/api/v3/coins/%v/market_chart/range?vs_currency=usd&from=%v&to=%v"
+
+// coinGeckoMaxHourlyRange is the widest range for which the history api returns hourly prices.
+const coinGeckoMaxHourlyRange = 89 * 24 * time.Hour
+
+// coinGeckoMaxIDs is the max number of ids in a single request to keep the url short.
+const coinGeckoMaxIDs = 200
+
+// Map: network -> coingecko asset platform id.
+var coinGeckoPlatforms = map[types.Network]string{
+	types.NetEthereum: "ethereum",
+	types.NetPolygon:  "polygon-pos",
+	types.NetBsc:      "binance-smart-chain",
+	types.NetIoTeX:    "iotex",
+}
+
+// CoinGecko fetches prices from the coingecko public api.
+type CoinGecko struct {
+	client *http.Client
+}
+
+func NewCoinGecko(client *http.Client) *CoinGecko {
+	return &CoinGecko{client: client}
+}
+
+func (self *CoinGecko) Name() string {
+	return ProviderCoinGecko
+}
+
+// Prices fetches the prices of all assets with as few requests as possible.
+func (self *CoinGecko) Prices(ctx context.Context, assets []Asset) (map[string]Quote, error) {
+	// Map: coin id -> symbols, different tokens can share the same coin.
+	symbols := make(map[string][]string)
+	ids := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		if asset.ID == "" {
+			continue
+		}
+		if _, ok := symbols[asset.ID]; !ok {
+			ids = append(ids, asset.ID)
+		}
+		symbols[asset.ID] = append(symbols[asset.ID], asset.Symbol)
+	}
+
+	quotes := make(map[string]Quote, len(assets))
+	for start := 0; start < len(ids); start += coinGeckoMaxIDs {
+		end := start + coinGeckoMaxIDs
+		if end > len(ids) {
+			end = len(ids)
+		}
+		data, err := self.get(ctx, fmt.Sprintf(CoinGeckoAPI, strings.Join(ids[start:end], ",")))
+		if err != nil {
+			return quotes, err
+		}
+		var result map[string]struct {
+			USD           float64 `json:"usd"`
+			LastUpdatedAt int64   `json:"last_updated_at"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return quotes, errors.Wrapf(err, "json marshal:%v", string(data))
+		}
+		for id, price := range result {
+			ts := time.Now()
+			if price.LastUpdatedAt != 0 {
+				ts = time.Unix(price.LastUpdatedAt, 0)
+			}
+			for _, symbol := range symbols[id] {
+				quotes[symbol] = Quote{
+					Symbol:    symbol,
+					Value:     price.USD,
+					Source:    self.Name(),
+					Timestamp: ts,
+				}
+			}
+		}
+	}
+	return quotes, nil
+}
+
+// ResolveID looks up the coingecko coin id by the token contract address.
+func (self *CoinGecko) ResolveID(ctx context.Context, network types.Network, address string) (string, error) {
+	platform, ok := coinGeckoPlatforms[network]
+	if !ok {
+		return "", errors.Wrapf(ErrNotFound, "no coingecko platform for network:%v", network)
+	}
+	data, err := self.get(ctx, fmt.Sprintf(CoinGeckoContractAPI, platform, strings.ToLower(address)))
+	if err != nil {
+		return "", err
+	}
+	var coin struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &coin); err != nil {
+		return "", errors.Wrapf(err, "json marshal:%v", string(data))
+	}
+	if coin.ID == "" {
+		return "", errors.Wrapf(ErrNotFound, "no coin id in response:%v", string(data))
+	}
+	return coin.ID, nil
+}
+
+// History returns the symbol prices between from and to at the given resolution.
+// Longer ranges are split into smaller windows when hourly prices are requested,
+// because the api returns daily prices for ranges longer than 90 days.
+func (self *CoinGecko) History(ctx context.Context, asset Asset, from, to time.Time, resolution string) ([]HistoricalPrice, error) {
+	if asset.ID == "" {
+		return nil, errors.Errorf("no coingecko id for symbol:%v", asset.Symbol)
+	}
+	id := asset.ID
+	window := to.Sub(from)
+	if resolution == ResolutionHourly {
+		window = coinGeckoMaxHourlyRange
+	}
+
+	prices := make([]HistoricalPrice, 0)
+	for start := from; start.Before(to); start = start.Add(window) {
+		end := start.Add(window)
+		if end.After(to) {
+			end = to
+		}
+		data, err := self.get(ctx, fmt.Sprintf(CoinGeckoHistoryAPI, id, start.Unix(), end.Unix()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetching history from:%v to:%v", start, end)
+		}
+		var result struct {
+			// Pairs of: unix timestamp in milliseconds, price.
+			Prices [][2]float64 `json:"prices"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, errors.Wrapf(err, "json marshal:%v", string(data))
+		}
+		for _, p := range result.Prices {
+			prices = append(prices, HistoricalPrice{
+				Timestamp: time.Unix(0, int64(p[0])*int64(time.Millisecond)),
+				Value:     p[1],
+			})
+		}
+	}
+	return Downsample(prices, resolution), nil
+}
+
+// get fetches the url and retries on failures.
+func (self *CoinGecko) get(ctx context.Context, url string) ([]byte, error) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	var errFinal error
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating request")
+		}
+		r, err := self.client.Do(req)
+		if err != nil {
+			errFinal = errors.Wrap(err, "fetching data")
+			continue
+		}
+
+		data, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			errFinal = errors.Wrap(err, "read response body")
+			continue
+		}
+
+		if r.StatusCode/100 != 2 {
+			// Not found won't change on retries.
+			if r.StatusCode == http.StatusNotFound {
+				return nil, errors.Wrapf(ErrNotFound, "response status code:%v, payload:%v", r.StatusCode, string(data))
+			}
+			errFinal = errors.Errorf("response status code not OK code:%v, payload:%v", r.StatusCode, string(data))
+			continue
+		}
+		return data, nil
+	}
+
+	return nil, errFinal
+}
@@ -0,0 +1,95 @@
+package price
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	ResolutionHourly = "hourly"
+	ResolutionDaily  = "daily"
+)
+
+// HistoryProvider is a price provider that can also serve past prices.
+type HistoryProvider interface {
+	PriceProvider
+	History(ctx context.Context, asset Asset, from, to time.Time, resolution string) ([]HistoricalPrice, error)
+}
+
+type HistoricalPrice struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// Downsample keeps the first price in every resolution bucket.
+// The prices are expected in ascending time order.
+func Downsample(prices []HistoricalPrice, resolution string) []HistoricalPrice {
+	bucket := time.Hour
+	if resolution == ResolutionDaily {
+		bucket = 24 * time.Hour
+	}
+	out := make([]HistoricalPrice, 0, len(prices))
+	var last time.Time
+	for _, p := range prices {
+		t := p.Timestamp.UTC().Truncate(bucket)
+		if len(out) > 0 && !t.After(last) {
+			continue
+		}
+		last = t
+		out = append(out, HistoricalPrice{Timestamp: t, Value: p.Value})
+	}
+	return out
+}
+
+// Backfill loads the past prices of the given assets from the first provider
+// that supports history and records them in the store.
+func (self *PriceTracker) Backfill(assets []Asset, from, to time.Time, resolution string) error {
+	if resolution != ResolutionHourly && resolution != ResolutionDaily {
+		return errors.Errorf("unknown resolution:%v", resolution)
+	}
+	if !from.Before(to) {
+		return errors.Errorf("invalid range from:%v to:%v", from, to)
+	}
+	var provider HistoryProvider
+	for _, p := range self.providers {
+		if hp, ok := p.(HistoryProvider); ok {
+			provider = hp
+			break
+		}
+	}
+	if provider == nil {
+		return errors.New("none of the configured providers supports history")
+	}
+
+	for _, asset := range assets {
+		symbol := asset.Symbol
+		select {
+		case <-self.ctx.Done():
+			return errors.New("context canceled")
+		default:
+		}
+		// The assets priced by their symbol have no history.
+		if asset.ID == "" {
+			level.Info(self.logger).Log("msg", "no price id, skipping the price history", "symbol", symbol)
+			continue
+		}
+		level.Info(self.logger).Log("msg", "backfilling prices", "symbol", symbol, "from", from, "to", to, "resolution", resolution)
+		ctx, cncl := context.WithTimeout(self.ctx, self.cfg.HistoryTimeout.Duration)
+		prices, err := provider.History(ctx, asset, from, to, resolution)
+		cncl()
+		if err != nil {
+			level.Error(self.logger).Log("msg", "fetching price history", "symbol", symbol, "err", err)
+			continue
+		}
+		for _, p := range prices {
+			if err := self.store.RecordPriceAt(symbol, p.Value, provider.Name(), p.Timestamp); err != nil {
+				return errors.Wrapf(err, "recording price symbol:%v", symbol)
+			}
+		}
+		level.Info(self.logger).Log("msg", "prices backfilled", "symbol", symbol, "count", len(prices))
+	}
+	return nil
+}
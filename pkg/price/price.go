@@ -2,60 +2,63 @@ package price
 
 import (
 	"context"
-	"crypto/tls"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"strconv"
-	"strings"
-
-	"github.com/davecgh/go-spew/spew"
-	"github.com/yalp/jsonpath"
-
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/davecgh/go-spew/spew"
+
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/logging"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/metrics"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 )
 
-// Map: symbol -> API ids.
-var symbolToIds = map[string]string{
-	"wbtc":   "bitcoin",
-	"weth":   "ethereum",
-	"uni":    "uniswap",
-	"busd":   "busd",
-	"usdc":   "usd-coin",
-	"link":   "link",
-	"usdt":   "tether",
-	"iotx":   "iotex",
-	"paxg":   "pax-gold",
-	"cyc":    "cyclone-protocol",
-	"wmatic": "matic-network",
-	"sushi":  "sushi",
-	"dai":    "dai",
-	"aave":   "aave",
-	"quick":  "quick",
-	"wbnb":   "wbnb",
-}
-var CoinGeckoAPI = "https://api.coingecko.com/api/v3/simple/price?ids=%v&vs_currencies=usd"
-
 const ComponentName = "price"
 
 type Config struct {
 	LogLevel string
+	// Interval between the price updates.
+	Interval format.Duration
+	// Timeout of the requests to every provider in an update.
+	Timeout format.Duration
+	// RequestTimeout of every http request to a provider.
+	RequestTimeout format.Duration
+	// ResolveTimeout of the lookup of the price id of a token by its address.
+	ResolveTimeout format.Duration
+	// HistoryTimeout of the backfill of the past prices of every symbol.
+	HistoryTimeout format.Duration
+	// Aggregation method used to combine prices from all providers: median or priority.
+	Aggregation string
+	// Providers in priority order.
+	Providers []ProviderConfig
+	// Tokens price ids, tokens that are not listed here are looked up by their address.
+	Tokens []TokenConfig
+	// Validation of the prices before they are recorded.
+	Validation ValidationConfig
 }
 
 // Track coin prices and add records in the influxdb.
 type PriceTracker struct {
-	logger log.Logger
-	cfg    Config
-	ctx    context.Context
-	stop   context.CancelFunc
-	store  *bridge.Store
+	logger    log.Logger
+	cfg       Config
+	ctx       context.Context
+	stop      context.CancelFunc
+	store     *bridge.Store
+	providers []PriceProvider
+	// Map: network:address -> price id from the config.
+	ids map[string]string
+
+	cache     *Cache
+	validator *Validator
+
+	mtx sync.Mutex
+	// Map: network:address -> price id looked up by the providers.
+	resolved map[string]resolvedID
+	unpriced []string
 }
 
 func New(logger log.Logger, ctx context.Context, store *bridge.Store, cfg Config) (*PriceTracker, error) {
@@ -63,47 +66,94 @@ func New(logger log.Logger, ctx context.Context, store *bridge.Store, cfg Config
 	if err != nil {
 		return nil, errors.Wrap(err, "apply filter logger")
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validating config")
+	}
+	// A single client so connections are reused between requests.
+	client := &http.Client{Timeout: cfg.RequestTimeout.Duration}
+	providers, err := NewProviders(cfg.Providers, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating price providers")
+	}
+	validator, err := NewValidator(cfg.Validation, store)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating price validator")
+	}
+	ids := make(map[string]string, len(cfg.Tokens))
+	for _, token := range cfg.Tokens {
+		ids[tokenKey(token.Network, token.Address)] = token.ID
+	}
 	ctx, stop := context.WithCancel(ctx)
 	return &PriceTracker{
-		logger: logger,
-		cfg:    cfg,
-		ctx:    ctx,
-		stop:   stop,
-		store:  store,
+		logger:    log.With(logger, "component", ComponentName),
+		cfg:       cfg,
+		ctx:       ctx,
+		stop:      stop,
+		store:     store,
+		providers: providers,
+		ids:       ids,
+		cache:     NewCache(),
+		validator: validator,
+		resolved:  make(map[string]resolvedID),
 	}, nil
 }
 
+// Validate checks the intervals, the timeouts, the aggregation method, the providers, the tokens and the validation limits.
+func (self Config) Validate() error {
+	if self.Interval.Duration <= 0 || self.Timeout.Duration <= 0 {
+		return errors.New("interval and timeout need to be positive")
+	}
+	if self.RequestTimeout.Duration <= 0 || self.ResolveTimeout.Duration <= 0 || self.HistoryTimeout.Duration <= 0 {
+		return errors.New("request, resolve and history timeouts need to be positive")
+	}
+	if _, err := Aggregate([]Quote{{}}, self.Aggregation); err != nil {
+		return errors.Wrap(err, "validating aggregation method")
+	}
+	if _, err := NewProviders(self.Providers, nil); err != nil {
+		return errors.Wrap(err, "validating price providers")
+	}
+	for _, token := range self.Tokens {
+		if token.Network == "" || token.Address == "" || token.ID == "" {
+			return errors.Errorf("token price id needs a network, address and id:%+v", token)
+		}
+	}
+	if self.Validation.MinSources > len(self.Providers) {
+		return errors.Errorf("min sources:%v can't be more than the providers:%v", self.Validation.MinSources, len(self.Providers))
+	}
+	return self.Validation.Validate()
+}
+
 func (self *PriceTracker) Start() error {
 	level.Info(self.logger).Log("msg", "starting price tracker")
-	// Update price every 2min.
-	ticker := time.NewTicker(120 * time.Second)
+	ticker := time.NewTicker(self.cfg.Interval.Duration)
 	for {
-		symbols, err := self.store.GetAllSymbols()
+		assets, err := self.Assets()
 		if err != nil {
-			level.Error(self.logger).Log("msg", "getting symbols", "err", err)
+			level.Error(self.logger).Log("msg", "getting assets", "err", err)
 		}
 
-		level.Debug(self.logger).Log("msg", "updating prices", "symbols", spew.Sdump(symbols))
-		for _, symbol := range symbols {
-			select {
-			case <-self.ctx.Done():
-				return errors.New("context canceled")
-			default:
-			}
-			ctx, cncl := context.WithTimeout(self.ctx, 2*time.Second)
-			defer cncl()
-			price, err := Fetch(ctx, symbolToIds[strings.ToLower(symbol)])
-			if err != nil {
-				level.Error(self.logger).Log("msg", "fetching price from api", "symbol", symbol, "err", err)
+		level.Debug(self.logger).Log("msg", "updating prices", "assets", spew.Sdump(assets))
+		quotes := self.Prices(assets)
+		var recorded int
+		for _, quote := range quotes {
+			if err := self.validator.Validate(quote.Quote, quote.Sources); err != nil {
+				level.Warn(self.logger).Log("msg", "price quarantined", "symbol", quote.Symbol, "price", quote.Value, "source", quote.Source, "reason", err)
+				metrics.PricesQuarantined.WithLabelValues(quote.Symbol).Inc()
+				if err := self.store.RecordQuarantinedPrice(quote.Symbol, quote.Value, quote.Source, err.Error()); err != nil {
+					level.Error(self.logger).Log("msg", "recording quarantined price", "err", err)
+				}
 				continue
 			}
-
-			level.Debug(self.logger).Log("msg", "recording price", "price", price)
-			err = self.store.RecordPrice(symbol, price)
+			level.Debug(self.logger).Log("msg", "recording price", "symbol", quote.Symbol, "price", quote.Value, "source", quote.Source, "age", quote.Age())
+			err = self.store.RecordPrice(quote.Symbol, quote.Value, quote.Source, quote.Age())
 			if err != nil {
 				level.Error(self.logger).Log("msg", "recording price", "err", err)
+				continue
 			}
+			self.cache.Set(quote.Quote)
+			recorded++
 		}
+		level.Info(self.logger).Log("msg", "prices updated", "count", recorded, "assets", len(assets))
 		select {
 		case <-self.ctx.Done():
 			return errors.New("context canceled")
@@ -116,94 +166,68 @@ func (self *PriceTracker) Stop() {
 	self.stop()
 }
 
-func Fetch(ctx context.Context, symbol string) (float64, error) {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	client := http.Client{Transport: tr}
-	ticker := time.NewTicker(1 * time.Second)
+// AggregatedQuote is the combined quote of an asset along with the quotes of all sources.
+type AggregatedQuote struct {
+	Quote
+	Sources []Quote
+}
 
-	var errFinal error
-	for i := 0; i < 5; i++ {
-		url := fmt.Sprintf(CoinGeckoAPI, symbol)
-		r, err := client.Get(url)
-		if err != nil {
-			errFinal = errors.Wrap(err, "fetching data")
-			select {
-			case <-ticker.C:
-				continue
-			case <-ctx.Done():
-				return 0, ctx.Err()
-			}
+// Prices asks the providers for the prices of all assets in a single batch per provider
+// and combines the results of every asset using the configured aggregation method.
+func (self *PriceTracker) Prices(assets []Asset) []AggregatedQuote {
+	// Map: symbol -> quotes in provider priority order.
+	all := make(map[string][]Quote, len(assets))
+	pending := assets
+	for _, provider := range self.providers {
+		if len(pending) == 0 {
+			break
 		}
-
-		data, err := ioutil.ReadAll(r.Body)
+		ctx, cncl := context.WithTimeout(self.ctx, self.cfg.Timeout.Duration)
+		quotes, err := provider.Prices(ctx, pending)
+		cncl()
+		metrics.PriceFetches.WithLabelValues(provider.Name(), metrics.Outcome(err)).Inc()
 		if err != nil {
-			errFinal = errors.Wrap(err, "read response body")
-			select {
-			case <-ticker.C:
-				continue
-			case <-ctx.Done():
-				return 0, ctx.Err()
-			}
+			level.Warn(self.logger).Log("msg", "fetching prices from provider", "provider", provider.Name(), "err", err)
 		}
-		r.Body.Close()
-
-		if r.StatusCode/100 != 2 {
-			errFinal = errors.Errorf("response status code not OK code:%v, payload:%v", r.StatusCode, string(data))
-			select {
-			case <-ticker.C:
-				continue
-			case <-ctx.Done():
-				return 0, ctx.Err()
+		for symbol, quote := range quotes {
+			all[symbol] = append(all[symbol], quote)
+		}
+		// No need to ask the rest for the assets that already have a price when the first one that answers wins,
+		// unless the price needs to be confirmed by more sources.
+		if self.cfg.Aggregation == AggregationPriority && self.cfg.Validation.MinSources <= 1 {
+			missing := make([]Asset, 0)
+			for _, asset := range pending {
+				if _, ok := all[asset.Symbol]; !ok {
+					missing = append(missing, asset)
+				}
 			}
+			pending = missing
 		}
-		var inputToParse interface{}
+	}
 
-		err = json.Unmarshal(data, &inputToParse)
-		if err != nil {
-			return 0, errors.Wrapf(err, "json marshal:%v", string(data))
+	out := make([]AggregatedQuote, 0, len(all))
+	for _, asset := range assets {
+		quotes, ok := all[asset.Symbol]
+		if !ok {
+			level.Error(self.logger).Log("msg", "no provider returned a price", "symbol", asset.Symbol, "id", asset.ID)
+			continue
 		}
-
-		output, err := jsonpath.Read(inputToParse, fmt.Sprintf(`$["%v"].usd`, symbol))
+		quote, err := Aggregate(quotes, self.cfg.Aggregation)
 		if err != nil {
-			return 0, errors.Wrapf(err, "json path read:%v", string(data))
+			level.Error(self.logger).Log("msg", "aggregating prices", "symbol", asset.Symbol, "err", err)
+			continue
 		}
-
-		return parseInterface(output)
+		out = append(out, AggregatedQuote{Quote: quote, Sources: quotes})
 	}
-
-	return 0, errFinal
-
+	return out
 }
 
-func parseInterface(data interface{}) (float64, error) {
-	// Expect result to be a slice of float or a single float value.
-	var resultList []interface{}
-	switch result := data.(type) {
-	case []interface{}:
-		resultList = result
-	default:
-		resultList = []interface{}{result}
-	}
-	// Parse each item of slice to a float.
-	var value float64
-	for i, a := range resultList {
-		strValue := fmt.Sprintf("%v", a)
-		// Normalize based on american locale.
-		strValue = strings.Replace(strValue, ",", "", -1)
-
-		switch i {
-		case 0:
-			val, err := strconv.ParseFloat(strValue, 64)
-			if err != nil {
-				return 0, errors.Wrapf(err, "value needs to be a valid float:%v", strValue)
-			}
-			value = val
-		case 1:
-
-		}
+// Latest returns the last tracked price of the symbol.
+// It fails with ErrStalePrice when the price is older than maxAge.
+func (self *PriceTracker) Latest(symbol string, maxAge time.Duration) (float64, error) {
+	quote, err := self.cache.Get(symbol, maxAge)
+	if err != nil {
+		return 0, err
 	}
-
-	return value, nil
+	return quote.Value, nil
 }
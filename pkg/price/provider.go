@@ -0,0 +1,98 @@
+package price
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// AggregationMedian picks the median of all provider prices.
+	AggregationMedian = "median"
+	// AggregationPriority picks the first provider, in config order, that returned a price.
+	AggregationPriority = "priority"
+)
+
+const (
+	ProviderCoinGecko = "coingecko"
+	ProviderStatic    = "static"
+)
+
+// PriceProvider is a source of coin prices in usd.
+type PriceProvider interface {
+	// Name of the source, recorded along with every price it produces.
+	Name() string
+	// Prices returns the quotes of all given assets it knows about keyed by the asset symbol.
+	Prices(ctx context.Context, assets []Asset) (map[string]Quote, error)
+}
+
+type ProviderConfig struct {
+	// Type of the provider: coingecko or static.
+	Type string
+	// Path to the json file with the prices for the static provider.
+	Path string
+}
+
+// Quote is a price produced by a single provider.
+type Quote struct {
+	Symbol string
+	Value  float64
+	Source string
+	// Timestamp when the source last updated the price.
+	Timestamp time.Time
+}
+
+// Age returns how old the price is.
+func (self Quote) Age() time.Duration {
+	return time.Since(self.Timestamp)
+}
+
+// NewProviders creates the providers in the same order as in the config.
+// All providers that use http share the given client.
+func NewProviders(cfgs []ProviderConfig, client *http.Client) ([]PriceProvider, error) {
+	providers := make([]PriceProvider, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		switch strings.ToLower(cfg.Type) {
+		case ProviderCoinGecko:
+			providers = append(providers, NewCoinGecko(client))
+		case ProviderStatic:
+			p, err := NewStatic(cfg.Path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "creating static provider path:%v", cfg.Path)
+			}
+			providers = append(providers, p)
+		default:
+			return nil, errors.Errorf("unknown price provider:%v", cfg.Type)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, errors.New("no price providers configured")
+	}
+	return providers, nil
+}
+
+// Aggregate combines the quotes of all providers into a single quote.
+// The quotes are expected in provider priority order.
+func Aggregate(quotes []Quote, method string) (Quote, error) {
+	if len(quotes) == 0 {
+		return Quote{}, errors.New("no quotes to aggregate")
+	}
+	switch method {
+	case AggregationPriority:
+		return quotes[0], nil
+	case AggregationMedian:
+		sorted := make([]Quote, len(quotes))
+		copy(sorted, quotes)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Value < sorted[j].Value
+		})
+		// Use the lower median on even counts so the result always comes from a real source.
+		return sorted[(len(sorted)-1)/2], nil
+	default:
+		return Quote{}, errors.Errorf("unknown aggregation method:%v", method)
+	}
+}
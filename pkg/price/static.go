@@ -0,0 +1,62 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Static serves fixed prices loaded from a json file of: symbol -> usd price.
+// Useful as a fallback for stable coins or tokens without a market.
+type Static struct {
+	prices map[string]float64
+}
+
+func NewStatic(path string) (*Static, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read prices file")
+	}
+	prices := make(map[string]float64)
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, errors.Wrap(err, "parse prices file")
+	}
+	// Symbols lookups are case insensitive.
+	static := &Static{prices: make(map[string]float64, len(prices))}
+	for symbol, price := range prices {
+		static.prices[strings.ToLower(symbol)] = price
+	}
+	return static, nil
+}
+
+func (self *Static) Name() string {
+	return ProviderStatic
+}
+
+// HasSymbol is whether the file has a price of the symbol.
+func (self *Static) HasSymbol(symbol string) bool {
+	_, ok := self.prices[strings.ToLower(symbol)]
+	return ok
+}
+
+func (self *Static) Prices(ctx context.Context, assets []Asset) (map[string]Quote, error) {
+	quotes := make(map[string]Quote, len(assets))
+	for _, asset := range assets {
+		price, ok := self.prices[strings.ToLower(asset.Symbol)]
+		if !ok {
+			continue
+		}
+		// Fixed prices never get old.
+		quotes[asset.Symbol] = Quote{
+			Symbol:    asset.Symbol,
+			Value:     price,
+			Source:    self.Name(),
+			Timestamp: time.Now(),
+		}
+	}
+	return quotes, nil
+}
@@ -0,0 +1,155 @@
+package price
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/pkg/errors"
+)
+
+type ValidationConfig struct {
+	// MaxDeviation is the max relative change from the last accepted price, 0.5 is 50%.
+	// Zero disables the check.
+	MaxDeviation float64
+	// Confirmations is the number of consecutive updates a deviating price
+	// needs to keep before it is accepted as the new price level, at least one when MaxDeviation is set.
+	Confirmations int
+	// Pegs of the stable coins, Map: symbol -> usd value.
+	Pegs map[string]float64
+	// MaxPegDeviation is the max relative distance from the peg.
+	MaxPegDeviation float64
+	// MinSources is the min number of sources that need to agree on the price.
+	MinSources int
+	// MaxSourceDeviation is the max relative distance from the aggregated price
+	// for a source to count as agreeing.
+	MaxSourceDeviation float64
+}
+
+// Validator rejects prices that are likely wrong, like zeros, sudden jumps and depegs.
+type Validator struct {
+	cfg   ValidationConfig
+	store *bridge.Store
+	pegs  map[string]float64
+	// Map: symbol -> last accepted price.
+	last map[string]float64
+	// Map: symbol -> deviating price waiting for confirmation.
+	pending map[string]pendingPrice
+}
+
+type pendingPrice struct {
+	value float64
+	count int
+}
+
+// Validate checks that the deviations aren't negative, a deviating price needs confirmations and the pegs are positive.
+func (self ValidationConfig) Validate() error {
+	if self.MaxDeviation < 0 || self.MaxPegDeviation < 0 || self.MaxSourceDeviation < 0 {
+		return errors.New("price deviations can't be negative")
+	}
+	if self.MaxDeviation > 0 && self.Confirmations < 1 {
+		return errors.New("a max deviation needs at least one confirmation")
+	}
+	for symbol, peg := range self.Pegs {
+		if peg <= 0 {
+			return errors.Errorf("invalid peg for symbol:%v peg:%v", symbol, peg)
+		}
+	}
+	return nil
+}
+
+func NewValidator(cfg ValidationConfig, store *bridge.Store) (*Validator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	pegs := make(map[string]float64, len(cfg.Pegs))
+	for symbol, peg := range cfg.Pegs {
+		pegs[strings.ToLower(symbol)] = peg
+	}
+	return &Validator{
+		cfg:     cfg,
+		store:   store,
+		pegs:    pegs,
+		last:    make(map[string]float64),
+		pending: make(map[string]pendingPrice),
+	}, nil
+}
+
+// Validate checks the aggregated quote against the quotes of all sources and the recent history.
+// It returns the reason when the quote needs to be quarantined.
+func (self *Validator) Validate(quote Quote, quotes []Quote) error {
+	symbol := strings.ToLower(quote.Symbol)
+	if quote.Value <= 0 || math.IsNaN(quote.Value) || math.IsInf(quote.Value, 0) {
+		return errors.Errorf("invalid price:%v", quote.Value)
+	}
+
+	if peg, ok := self.pegs[symbol]; ok && self.cfg.MaxPegDeviation > 0 {
+		if d := deviation(quote.Value, peg); d > self.cfg.MaxPegDeviation {
+			return errors.Errorf("price:%v is %.2f%% off the peg:%v", quote.Value, d*100, peg)
+		}
+	}
+
+	if self.cfg.MinSources > 1 {
+		var agreeing int
+		for _, q := range quotes {
+			if deviation(q.Value, quote.Value) <= self.cfg.MaxSourceDeviation {
+				agreeing++
+			}
+		}
+		if agreeing < self.cfg.MinSources {
+			return errors.Errorf("price:%v confirmed by %v sources, need %v", quote.Value, agreeing, self.cfg.MinSources)
+		}
+	}
+
+	if err := self.checkHistory(symbol, quote.Value); err != nil {
+		return err
+	}
+	self.last[symbol] = quote.Value
+	delete(self.pending, symbol)
+	return nil
+}
+
+// checkHistory rejects sudden jumps from the last accepted price
+// unless the new price level holds for the configured number of updates.
+func (self *Validator) checkHistory(symbol string, value float64) error {
+	if self.cfg.MaxDeviation == 0 {
+		return nil
+	}
+	last, ok := self.last[symbol]
+	if !ok {
+		// Use the last recorded price after a restart.
+		price, err := self.store.PriceAt(symbol, time.Now())
+		if err != nil {
+			// Nothing to compare with.
+			return nil
+		}
+		last = price
+		self.last[symbol] = last
+	}
+	d := deviation(value, last)
+	if d <= self.cfg.MaxDeviation {
+		return nil
+	}
+
+	pending, ok := self.pending[symbol]
+	if ok && deviation(value, pending.value) <= self.cfg.MaxDeviation {
+		pending.count++
+	} else {
+		pending = pendingPrice{count: 1}
+	}
+	pending.value = value
+	self.pending[symbol] = pending
+	if pending.count > self.cfg.Confirmations {
+		return nil
+	}
+	return errors.Errorf("price:%v is %.2f%% off the last price:%v, confirmations:%v/%v", value, d*100, last, pending.count, self.cfg.Confirmations)
+}
+
+// deviation returns the relative distance of the value from the reference.
+func deviation(value, reference float64) float64 {
+	if reference == 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(value/reference - 1)
+}
@@ -0,0 +1,21 @@
+package types
+
+import "time"
+
+const (
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// AlertDelivery is an entry of the alerts delivery log.
+type AlertDelivery struct {
+	Time time.Time
+	// Key identifies the alert, the same alert is delivered once to every notifier.
+	Key      string
+	Alert    string
+	Notifier string
+	// Status is delivered or failed.
+	Status   string
+	Attempts int
+	Error    string
+}
@@ -0,0 +1,83 @@
+package types
+
+import "time"
+
+// Query selects the stored series, empty filters match everything.
+type Query struct {
+	From     time.Time
+	To       time.Time
+	Interval time.Duration
+	Bridge   Bridge
+	Side     BridgeSide
+	Network  Network
+	Symbol   string
+	Limit    int
+}
+
+// TransferSearch selects stored transfers, empty filters match everything.
+type TransferSearch struct {
+	Query
+	Hash      string
+	DepositID string
+	// Sender, Recipient and Address are checksummed like the stored addresses.
+	Sender    string
+	Recipient string
+	// Address matches both the sender and the recipient.
+	Address string
+	// Token is the token symbol or address.
+	Token string
+	// Cursor is the position of the last transfer of the previous page.
+	Cursor    *Cursor
+	Ascending bool
+}
+
+// Cursor is a position in the transfers ordered by time, hash and receipt id.
+type Cursor struct {
+	Time      time.Time
+	Hash      string
+	DepositID string
+}
+
+// AddressActivity totals the transfers of an address with the same bridge, token and counterparty.
+type AddressActivity struct {
+	Bridge       Bridge
+	Symbol       string
+	Counterparty string
+	// Deposit is set for the transfers sent by the address.
+	Deposit   bool
+	Count     int64
+	Amount    float64
+	AmountUSD float64
+	First     time.Time
+	Last      time.Time
+}
+
+type VolumePoint struct {
+	Time       time.Time
+	Bridge     Bridge
+	BridgeSide BridgeSide
+	Symbol     string
+	Amount     float64
+	AmountUSD  float64
+	Count      int64
+}
+
+type TVLPoint struct {
+	Time     time.Time
+	Network  Network
+	Symbol   string
+	Token    string
+	Value    float64
+	ValueUSD float64
+	// HasUSD is false when the usd value wasn't recorded, like when the price was stale.
+	HasUSD bool
+}
+
+type PricePoint struct {
+	Time   time.Time
+	Symbol string
+	Source string
+	Value  float64
+	// Age of the price at the source.
+	Age time.Duration
+}
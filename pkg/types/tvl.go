@@ -10,7 +10,18 @@ const (
 )
 
 type TVLData struct {
-	Value   float64
+	Value float64
+	// ValueUSD is zero when there is no recent price.
+	ValueUSD float64
+	Network  Network
+	Symbol   string
+	// Token contract address.
+	Token string
+}
+
+// Token is a bridged token on a network.
+type Token struct {
 	Network Network
+	Address string
 	Symbol  string
 }
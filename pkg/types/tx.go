@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type Bridge string
 
 const (
@@ -16,14 +18,30 @@ const (
 )
 
 type Transaction struct {
-	From       string
-	To         string
-	Hash       string
-	BlockNo    uint64
-	Bridge     Bridge
-	Amount     float64
+	From string
+	To   string
+	Hash string
+	// Token is the address of the bridged token on the deposit chain.
+	Token string
+	// DepositID is the id of the receipt emitted by the token cashier.
+	DepositID string
+	BlockNo   uint64
+	Bridge    Bridge
+	Amount    float64
+	// AmountUSD is the amount value in usd at the block time, zero when no price is known.
+	AmountUSD float64
+	// Fee paid for the transfer in the native coin of the deposit chain.
+	Fee        float64
 	BridgeSide BridgeSide
 	Symbol     string
 	Deposit    bool
 	Timestamp  uint64
+	// LogIndex of the event in its block.
+	LogIndex uint
+}
+
+// Time of the stored transfer point, the block time offset by the log index in nanoseconds
+// so the transfers of the same sender and token in a block don't share a point.
+func (self Transaction) Time() time.Time {
+	return time.Unix(int64(self.Timestamp), int64(self.LogIndex))
 }
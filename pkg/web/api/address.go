@@ -0,0 +1,134 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/route"
+)
+
+// address summarizes the activity of an address on all bridges and sides,
+// with the totals aggregated by the db and a page of its transfers.
+// The next pages are fetched with the cursor of the previous page.
+func (api *API) address(r *http.Request) apiFuncResult {
+	addr, err := format.ParseAddress(route.Param(r.Context(), "addr"))
+	if err != nil {
+		return invalidParamError(err, "addr")
+	}
+	s := types.TransferSearch{
+		Query: types.Query{
+			From:  time.Unix(0, 0),
+			To:    time.Now(),
+			Limit: defaultTransfers,
+		},
+		Address: addr.Hex(),
+	}
+	params := r.URL.Query()
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxTransfers {
+			return invalidParamError(errors.Errorf("limit needs to be between 1 and %v", maxTransfers), "limit")
+		}
+		s.Limit = limit
+	}
+	if v := params.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return invalidParamError(err, "cursor")
+		}
+		s.Cursor = cursor
+	}
+
+	activity, err := api.store.AddressActivity(r.Context(), addr.Hex())
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorExec, errors.Wrap(err, "getting address activity")}}
+	}
+	data := &AddressProfile{
+		Version:   ModelVersion,
+		Address:   addr.Hex(),
+		IoAddress: format.IoTeXAddress(addr),
+	}
+	data.Transfers, data.NextCursor, err = api.searchPage(r.Context(), s)
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorExec, errors.Wrap(err, "getting address transfers")}}
+	}
+
+	var (
+		tokens         = newAddressTotals()
+		bridges        = newAddressTotals()
+		counterparties = newAddressTotals()
+	)
+	for _, a := range activity {
+		first, last := a.First.UTC(), a.Last.UTC()
+		if data.FirstActivity == nil || first.Before(*data.FirstActivity) {
+			data.FirstActivity = &first
+		}
+		if data.LastActivity == nil || last.After(*data.LastActivity) {
+			data.LastActivity = &last
+		}
+
+		if a.Deposit {
+			data.Deposits += a.Count
+		} else {
+			data.Settlements += a.Count
+		}
+		tokens.add(a.Symbol, a)
+		bridges.add(string(a.Bridge), a)
+		// Self transfers between chains have no counterparty.
+		if a.Counterparty != "" && !strings.EqualFold(a.Counterparty, addr.Hex()) {
+			counterparties.add(a.Counterparty, a)
+		}
+	}
+	data.Tokens = tokens.sorted()
+	data.Bridges = bridges.sorted()
+	data.Counterparties = counterparties.sorted()
+	return apiFuncResult{data, nil}
+}
+
+type addressTotals map[string]*AddressTotal
+
+func newAddressTotals() addressTotals {
+	return make(map[string]*AddressTotal)
+}
+
+func (self addressTotals) add(name string, a types.AddressActivity) {
+	t, ok := self[name]
+	if !ok {
+		t = &AddressTotal{Name: name}
+		self[name] = t
+	}
+	if a.Deposit {
+		t.Deposits += a.Count
+		t.Sent += a.Amount
+		t.SentUSD += a.AmountUSD
+		return
+	}
+	t.Settlements += a.Count
+	t.Received += a.Amount
+	t.ReceivedUSD += a.AmountUSD
+}
+
+// sorted returns the totals with the biggest usd value first.
+func (self addressTotals) sorted() []AddressTotal {
+	out := make([]AddressTotal, 0, len(self))
+	for _, t := range self {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		vi, vj := out[i].SentUSD+out[i].ReceivedUSD, out[j].SentUSD+out[j].ReceivedUSD
+		if vi != vj {
+			return vi > vj
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
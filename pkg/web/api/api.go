@@ -6,10 +6,11 @@ package api
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"os"
 	"time"
 
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
@@ -31,13 +32,15 @@ const (
 type errorType string
 
 const (
-	errorTimeout     errorType = "timeout"
-	errorCanceled    errorType = "canceled"
-	errorExec        errorType = "execution"
-	errorBadData     errorType = "bad_data"
-	errorInternal    errorType = "internal"
-	errorUnavailable errorType = "unavailable"
-	errorNotFound    errorType = "not_found"
+	errorTimeout      errorType = "timeout"
+	errorCanceled     errorType = "canceled"
+	errorExec         errorType = "execution"
+	errorBadData      errorType = "bad_data"
+	errorInternal     errorType = "internal"
+	errorUnavailable  errorType = "unavailable"
+	errorNotFound     errorType = "not_found"
+	errorUnauthorized errorType = "unauthorized"
+	errorRateLimited  errorType = "rate_limited"
 )
 
 var (
@@ -70,9 +73,14 @@ type apiFunc func(r *http.Request) apiFuncResult
 // API can register a set of endpoints in a router and handle
 // them using the provided storage and query engine.
 type API struct {
-	now     func() time.Time
-	logger  log.Logger
-	readAPI api.QueryAPI
+	now    func() time.Time
+	logger log.Logger
+	// queryAPI reads with the read-only token of the query endpoint, nil without it.
+	queryAPI api.QueryAPI
+	store    *bridge.Store
+	queryCfg QueryConfig
+	cache    *responseCache
+	auth     *auth
 }
 
 // New returns an initialized API type.
@@ -80,16 +88,36 @@ func New(
 	logger log.Logger,
 	ctx context.Context,
 	tsDB influxdb2.Client,
-) *API {
+	store *bridge.Store,
+	queryCfg QueryConfig,
+	cacheCfg CacheConfig,
+	authCfg AuthConfig,
+) (*API, error) {
+	auth, err := newAuth(authCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating auth")
+	}
 
-	readAPI := tsDB.QueryAPI("my-org")
 	a := &API{
-		readAPI: readAPI,
-		now:     time.Now,
-		logger:  logger,
+		now:      time.Now,
+		logger:   logger,
+		store:    store,
+		queryCfg: queryCfg,
+		cache:    newResponseCache(cacheCfg),
+		auth:     auth,
+	}
+	go a.cache.watch(ctx, store.Events())
+
+	if token := os.Getenv(QueryTokenEnv); token != "" {
+		queryDB := influxdb2.NewClientWithOptions(tsDB.ServerURL(), token, tsDB.Options())
+		a.queryAPI = queryDB.QueryAPI("my-org")
+		go func() {
+			<-ctx.Done()
+			queryDB.Close()
+		}()
 	}
 
-	return a
+	return a, nil
 }
 
 func setUnavailStatusOnTSDBNotReady(r apiFuncResult) apiFuncResult {
@@ -101,8 +129,8 @@ func setUnavailStatusOnTSDBNotReady(r apiFuncResult) apiFuncResult {
 
 // Register the API's endpoints in the given router.
 func (api *API) Register(r *route.Router) {
-	wrap := func(f apiFunc) http.HandlerFunc {
-		hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handle := func(f apiFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
 			setupHeader(w)
 			result := setUnavailStatusOnTSDBNotReady(f(r))
 			if result.err != nil {
@@ -115,13 +143,30 @@ func (api *API) Register(r *route.Router) {
 				return
 			}
 			w.WriteHeader(http.StatusNoContent)
-		})
+		}
+	}
+	wrap := func(f apiFunc) http.HandlerFunc {
+		return httputil.CompressionHandler{
+			Handler: handle(f),
+		}.ServeHTTP
+	}
+	// cached responses are kept for the endpoint ttl or until new data is stored.
+	cached := func(endpoint string, f apiFunc) http.HandlerFunc {
 		return httputil.CompressionHandler{
-			Handler: hf,
+			Handler: api.cache.wrap(endpoint, handle(f)),
 		}.ServeHTTP
 	}
 
 	r.Post("/query", wrap(api.query))
+	r.Get("/volume", cached("volume", api.volume))
+	r.Get("/tvl", cached("tvl", api.tvl))
+	r.Get("/prices", cached("prices", api.prices))
+	r.Get("/transfers", cached("transfers", api.transfers))
+	r.Get("/transfers/search", cached("transfers/search", api.searchTransfers))
+	r.Get("/address/:addr", cached("address", api.address))
+	r.Get("/export/:kind", api.export)
+	r.Get("/stream", api.stream)
+	r.Get("/usage", wrap(api.usage))
 }
 
 type queryData struct {
@@ -135,23 +180,35 @@ func invalidParamError(err error, parameter string) apiFuncResult {
 }
 
 func (api *API) query(r *http.Request) (result apiFuncResult) {
-	ctx := r.Context()
+	if err := api.authorizeQuery(r); err != nil {
+		return apiFuncResult{nil, err}
+	}
+	ctx, cncl := context.WithTimeout(r.Context(), api.queryCfg.Timeout.Duration)
+	defer cncl()
 	ctx = httputil.ContextFromRequest(ctx, r)
 
-	// Get parser flux query result
-	q, err := ioutil.ReadAll(r.Body)
+	var sel Selection
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	if err := json.NewDecoder(r.Body).Decode(&sel); err != nil {
+		return invalidParamError(err, "reading the query from the body")
+	}
+	q, err := api.sandboxQuery(sel, api.now())
 	if err != nil {
-		return invalidParamError(err, "reading query from body")
+		return invalidParamError(err, "query")
 	}
-	res, err := api.readAPI.Query(ctx, string(q))
+	res, err := api.queryAPI.Query(ctx, q)
 	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return apiFuncResult{nil, &apiError{errorTimeout, errors.Errorf("query timeout after %v", api.queryCfg.Timeout.Duration)}}
+		}
 		return invalidParamError(err, "query")
 	}
+	defer res.Close()
 
 	if res.Err() != nil {
 		return apiFuncResult{nil, returnAPIError(res.Err())}
 	}
-	sliced, err := toSlice(res)
+	sliced, err := toSlice(res, api.queryCfg.MaxRows)
 	if err != nil {
 		return invalidParamError(err, "parsing influxdb results")
 	}
@@ -192,7 +249,7 @@ func setupHeader(rw http.ResponseWriter) {
 	rw.Header().Set("Content-Type", "application/json")
 	rw.Header().Set("Access-Control-Allow-Origin", "*")
 	rw.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-	rw.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+	rw.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key")
 }
 func (api *API) respondError(w http.ResponseWriter, apiErr *apiError, data interface{}) {
 	setupHeader(w)
@@ -222,6 +279,10 @@ func (api *API) respondError(w http.ResponseWriter, apiErr *apiError, data inter
 		code = http.StatusInternalServerError
 	case errorNotFound:
 		code = http.StatusNotFound
+	case errorUnauthorized:
+		code = http.StatusUnauthorized
+	case errorRateLimited:
+		code = http.StatusTooManyRequests
 	default:
 		code = http.StatusInternalServerError
 	}
@@ -233,14 +294,21 @@ func (api *API) respondError(w http.ResponseWriter, apiErr *apiError, data inter
 	}
 }
 
-// toMap converts api.QueryTableResult to a []interface{}.
-func toSlice(res *api.QueryTableResult) ([]interface{}, error) {
+// toMap converts api.QueryTableResult to a []interface{}
+// and returns an error when there are more than maxRows rows.
+func toSlice(res *api.QueryTableResult, maxRows int) ([]interface{}, error) {
 	out := make([]interface{}, 0)
 	for res.Next() {
 		if res.Err() != nil {
 			return nil, errors.Wrap(res.Err(), "error while iterating over api.QueryTableResult")
 		}
+		if len(out) == maxRows {
+			return nil, errors.Errorf("the query returned more than %v rows", maxRows)
+		}
 		out = append(out, res.Record().Values())
 	}
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "error while iterating over api.QueryTableResult")
+	}
 	return out, nil
 }
@@ -0,0 +1,256 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// AuthConfig of the api keys and the rate limits.
+type AuthConfig struct {
+	// Required rejects the requests without a valid key,
+	// otherwise anonymous requests are limited per IP.
+	Required bool
+	Keys     []APIKey
+	// Rate is the requests per second and Burst the max requests at once
+	// for every IP without a key and for the keys without their own limits.
+	// Zero rate disables the limits.
+	Rate  float64
+	Burst int
+	// KeyIPRate and KeyIPBurst limit every client IP of the requests with a key, on top of the limits of the key.
+	// Zero rate disables them.
+	KeyIPRate  float64
+	KeyIPBurst int
+	// TrustProxy uses the last X-Forwarded-For address as the client IP,
+	// the one added by the proxy in front of the service. The ones before it are set by the client.
+	TrustProxy bool
+}
+
+type APIKey struct {
+	Name string
+	Key  string
+	// Rate and Burst override the default limits for the key.
+	Rate  float64
+	Burst int
+}
+
+// apiKeyContextKey is the request context key of the caller api key name.
+type apiKeyContextKey struct{}
+
+// authExempt are the paths without authentication and limits.
+var authExempt = []string{"/healthz", "/readyz", "/metrics"}
+
+// maxBuckets limits the memory used by the per IP limits.
+const maxBuckets = 100000
+
+// KeyUsage counts the requests of an api key.
+type KeyUsage struct {
+	Name     string    `json:"name"`
+	Requests uint64    `json:"requests"`
+	Limited  uint64    `json:"limited"`
+	Since    time.Time `json:"since"`
+}
+
+type auth struct {
+	mtx  sync.Mutex
+	cfg  AuthConfig
+	now  func() time.Time
+	keys map[string]APIKey
+	// Map: key name or client IP -> bucket.
+	buckets map[string]*rate.Limiter
+	// Map: key name -> usage.
+	usage map[string]*KeyUsage
+}
+
+// Validate checks that every key has a name, a long enough key and its own name.
+func (self AuthConfig) Validate() error {
+	names := make(map[string]bool)
+	for _, k := range self.Keys {
+		if k.Name == "" || len(k.Key) < 16 {
+			return errors.Errorf("api key:%q needs a name and at least 16 characters", k.Name)
+		}
+		if names[k.Name] {
+			return errors.Errorf("duplicate api key name:%v", k.Name)
+		}
+		names[k.Name] = true
+	}
+	return nil
+}
+
+func newAuth(cfg AuthConfig) (*auth, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	self := &auth{
+		cfg:     cfg,
+		now:     time.Now,
+		keys:    make(map[string]APIKey),
+		buckets: make(map[string]*rate.Limiter),
+		usage:   make(map[string]*KeyUsage),
+	}
+	for _, k := range cfg.Keys {
+		self.keys[k.Key] = k
+		self.usage[k.Name] = &KeyUsage{Name: k.Name, Since: self.now()}
+	}
+	return self, nil
+}
+
+// key returns the api key of the request from the X-API-Key header or the api_key parameter.
+func (self *auth) key(r *http.Request) (APIKey, bool, error) {
+	given := r.Header.Get("X-API-Key")
+	if given == "" {
+		given = r.URL.Query().Get("api_key")
+	}
+	if given == "" {
+		return APIKey{}, false, nil
+	}
+	for key, k := range self.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(given)) == 1 {
+			return k, true, nil
+		}
+	}
+	return APIKey{}, false, errors.New("invalid api key")
+}
+
+func (self *auth) clientIP(r *http.Request) string {
+	if self.cfg.TrustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			addrs := strings.Split(fwd, ",")
+			return strings.TrimSpace(addrs[len(addrs)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// allow takes a token from the bucket of the key or the client IP.
+// It returns whether a token was available, the remaining tokens and how long until the next token.
+func (self *auth) allow(bucket string, limit float64, burst int) (bool, float64, time.Duration) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	now := self.now()
+	b, ok := self.buckets[bucket]
+	if !ok {
+		if len(self.buckets) >= maxBuckets {
+			self.dropFull(now)
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		b = rate.NewLimiter(rate.Limit(limit), burst)
+		self.buckets[bucket] = b
+	}
+	r := b.ReserveN(now, 1)
+	if wait := r.DelayFrom(now); wait > 0 {
+		r.CancelAt(now)
+		return false, math.Max(0, b.TokensAt(now)), wait
+	}
+	return true, math.Max(0, b.TokensAt(now)), 0
+}
+
+// dropFull removes the buckets that refilled, they are the same as new ones.
+func (self *auth) dropFull(now time.Time) {
+	for k, b := range self.buckets {
+		if b.TokensAt(now) >= float64(b.Burst()) {
+			delete(self.buckets, k)
+		}
+	}
+}
+
+func (self *auth) count(name string, limited bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	u := self.usage[name]
+	u.Requests++
+	if limited {
+		u.Limited++
+	}
+}
+
+func (self *auth) keyUsage(name string) KeyUsage {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	return *self.usage[name]
+}
+
+// Authenticate checks the api key and the rate limits before the request reaches the handler.
+func (api *API) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || contains(authExempt, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key, ok, err := api.auth.key(r)
+		ip := api.auth.clientIP(r)
+		if err != nil {
+			// Invalid keys are limited per IP like the anonymous requests, so keys can't be guessed quickly.
+			if api.limit(w, "ip:"+ip, api.auth.cfg.Rate, api.auth.cfg.Burst) {
+				api.respondError(w, &apiError{errorUnauthorized, err}, nil)
+			}
+			return
+		}
+		if !ok && api.auth.cfg.Required {
+			api.respondError(w, &apiError{errorUnauthorized, errors.New("missing api key, use the X-API-Key header")}, nil)
+			return
+		}
+
+		if ok {
+			rate, burst := api.auth.cfg.Rate, api.auth.cfg.Burst
+			if key.Rate > 0 {
+				rate, burst = key.Rate, key.Burst
+			}
+			// The key limits go last so their headers are the ones returned.
+			allowed := api.limit(w, "key-ip:"+ip, api.auth.cfg.KeyIPRate, api.auth.cfg.KeyIPBurst) &&
+				api.limit(w, "key:"+key.Name, rate, burst)
+			api.auth.count(key.Name, !allowed)
+			if !allowed {
+				return
+			}
+		} else if !api.limit(w, "ip:"+ip, api.auth.cfg.Rate, api.auth.cfg.Burst) {
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key.Name)))
+	})
+}
+
+// limit takes a token from the bucket and answers with a 429 when there is none.
+// Zero rate doesn't limit.
+func (api *API) limit(w http.ResponseWriter, bucket string, rate float64, burst int) bool {
+	if rate <= 0 {
+		return true
+	}
+	allowed, remaining, retry := api.auth.allow(bucket, rate, burst)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(burst))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(remaining)))
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+		api.respondError(w, &apiError{errorRateLimited, errors.Errorf("rate limit exceeded, retry after %v", retry.Round(time.Millisecond))}, nil)
+		return false
+	}
+	return true
+}
+
+// usage returns the request counters of the caller api key.
+func (api *API) usage(r *http.Request) apiFuncResult {
+	name, _ := r.Context().Value(apiKeyContextKey{}).(string)
+	if name == "" {
+		return apiFuncResult{nil, &apiError{errorUnauthorized, errors.New("usage is only available with an api key")}}
+	}
+	usage := api.auth.keyUsage(name)
+	return apiFuncResult{&usage, nil}
+}
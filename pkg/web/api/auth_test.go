@@ -0,0 +1,141 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestAuthenticateLimitHeaders(t *testing.T) {
+	const key = "0123456789abcdef"
+	type step struct {
+		// after is the time since the first request.
+		after  time.Duration
+		path   string
+		key    string
+		status int
+		// Expected headers, empty for a missing header.
+		limit, remaining, retryAfter string
+	}
+	cases := []struct {
+		name  string
+		cfg   AuthConfig
+		steps []step
+	}{
+		{
+			name: "ip limit",
+			cfg:  AuthConfig{Rate: 1, Burst: 2},
+			steps: []step{
+				{status: http.StatusOK, limit: "2", remaining: "1"},
+				{status: http.StatusOK, limit: "2", remaining: "0"},
+				{status: http.StatusTooManyRequests, limit: "2", remaining: "0", retryAfter: "1"},
+				{after: time.Second, status: http.StatusOK, limit: "2", remaining: "0"},
+			},
+		},
+		{
+			name: "slow rate rounds the retry up",
+			cfg:  AuthConfig{Rate: 0.1, Burst: 1},
+			steps: []step{
+				{status: http.StatusOK, limit: "1", remaining: "0"},
+				{after: 500 * time.Millisecond, status: http.StatusTooManyRequests, limit: "1", remaining: "0", retryAfter: "10"},
+			},
+		},
+		{
+			name: "key with its own limits",
+			cfg:  AuthConfig{Rate: 1, Burst: 1, Keys: []APIKey{{Name: "app", Key: key, Rate: 1, Burst: 3}}},
+			steps: []step{
+				{key: key, status: http.StatusOK, limit: "3", remaining: "2"},
+				{key: key, status: http.StatusOK, limit: "3", remaining: "1"},
+				// The ip of the key isn't limited by the anonymous limits.
+				{status: http.StatusOK, limit: "1", remaining: "0"},
+				{key: key, status: http.StatusOK, limit: "3", remaining: "0"},
+				{key: key, status: http.StatusTooManyRequests, limit: "3", remaining: "0", retryAfter: "1"},
+			},
+		},
+		{
+			name: "key ip limit goes first",
+			cfg:  AuthConfig{Rate: 1, Burst: 5, KeyIPRate: 1, KeyIPBurst: 1, Keys: []APIKey{{Name: "app", Key: key}}},
+			steps: []step{
+				{key: key, status: http.StatusOK, limit: "5", remaining: "4"},
+				{key: key, status: http.StatusTooManyRequests, limit: "1", remaining: "0", retryAfter: "1"},
+			},
+		},
+		{
+			name: "invalid keys are limited per ip",
+			cfg:  AuthConfig{Rate: 1, Burst: 1, Keys: []APIKey{{Name: "app", Key: key}}},
+			steps: []step{
+				{key: "invalid", status: http.StatusUnauthorized, limit: "1", remaining: "0"},
+				{key: "invalid", status: http.StatusTooManyRequests, limit: "1", remaining: "0", retryAfter: "1"},
+			},
+		},
+		{
+			name: "required key",
+			cfg:  AuthConfig{Required: true, Rate: 1, Burst: 1, Keys: []APIKey{{Name: "app", Key: key}}},
+			steps: []step{
+				{status: http.StatusUnauthorized},
+				{key: key, status: http.StatusOK, limit: "1", remaining: "0"},
+			},
+		},
+		{
+			name: "exempt path",
+			cfg:  AuthConfig{Required: true, Rate: 1, Burst: 1},
+			steps: []step{
+				{path: "/healthz", status: http.StatusOK},
+				{path: "/healthz", status: http.StatusOK},
+			},
+		},
+		{
+			name: "no limits",
+			cfg:  AuthConfig{},
+			steps: []step{
+				{status: http.StatusOK},
+				{status: http.StatusOK},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			a, err := newAuth(c.cfg)
+			if err != nil {
+				t.Fatalf("creating auth:%v", err)
+			}
+			start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+			now := start
+			a.now = func() time.Time { return now }
+			api := &API{logger: log.NewNopLogger(), auth: a}
+			handler := api.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			for i, s := range c.steps {
+				now = start.Add(s.after)
+				path := s.path
+				if path == "" {
+					path = "/api/v1/tvl"
+				}
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if s.key != "" {
+					req.Header.Set("X-API-Key", s.key)
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				if w.Code != s.status {
+					t.Fatalf("step:%v unexpected status:%v, expected:%v", i, w.Code, s.status)
+				}
+				for header, expected := range map[string]string{
+					"X-RateLimit-Limit":     s.limit,
+					"X-RateLimit-Remaining": s.remaining,
+					"Retry-After":           s.retryAfter,
+				} {
+					if got := w.Header().Get(header); got != expected {
+						t.Fatalf("step:%v unexpected %v:%q, expected:%q", i, header, got, expected)
+					}
+				}
+			}
+		})
+	}
+}
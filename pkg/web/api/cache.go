@@ -0,0 +1,246 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// CacheConfig of the read endpoints responses.
+type CacheConfig struct {
+	// MaxEntries is the max number of cached responses, zero disables the cache.
+	MaxEntries int
+	// TTLs of the endpoints responses, Map: endpoint -> ttl.
+	// Endpoints without a ttl aren't cached.
+	TTLs map[string]format.Duration
+}
+
+// Map: store event -> endpoints with responses that the event invalidates.
+// The address responses are only invalidated by the transfers of the address.
+var cacheInvalidations = map[bridge.EventType][]string{
+	bridge.EventTransfer: {"volume", "transfers", "transfers/search"},
+	bridge.EventTVL:      {"tvl"},
+	bridge.EventPrice:    {"prices"},
+}
+
+type cacheEntry struct {
+	endpoint string
+	status   int
+	header   http.Header
+	body     []byte
+	etag     string
+	expires  time.Time
+}
+
+// responseCache keeps the successful responses keyed by the endpoint and the normalized query parameters.
+type responseCache struct {
+	mtx     sync.Mutex
+	cfg     CacheConfig
+	now     func() time.Time
+	entries map[string]*cacheEntry
+}
+
+func newResponseCache(cfg CacheConfig) *responseCache {
+	return &responseCache{
+		cfg:     cfg,
+		now:     time.Now,
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// cacheKey sorts the query parameters so the same query always has the same key.
+func cacheKey(r *http.Request) string {
+	return r.URL.Path + "?" + r.URL.Query().Encode()
+}
+
+// wrap serves the endpoint responses from the cache and
+// answers If-None-Match requests with the ETag of the response.
+func (self *responseCache) wrap(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	ttl := self.cfg.TTLs[endpoint].Duration
+	if self.cfg.MaxEntries == 0 || ttl <= 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := cacheKey(r)
+		entry := self.get(key)
+		if entry == nil {
+			rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+			next(rec, r)
+			entry = &cacheEntry{
+				endpoint: endpoint,
+				status:   rec.status,
+				header:   rec.header,
+				body:     rec.body.Bytes(),
+				etag:     etag(rec.body.Bytes()),
+				expires:  self.now().Add(ttl),
+			}
+			// Errors aren't cached.
+			if rec.status == http.StatusOK {
+				self.set(key, entry)
+			}
+		}
+
+		for k, v := range entry.header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("ETag", entry.etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if entry.status == http.StatusOK && etagMatch(r.Header.Get("If-None-Match"), entry.etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(entry.status)
+		w.Write(entry.body)
+	}
+}
+
+func (self *responseCache) get(key string) *cacheEntry {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	entry, ok := self.entries[key]
+	if !ok {
+		return nil
+	}
+	if self.now().After(entry.expires) {
+		delete(self.entries, key)
+		return nil
+	}
+	return entry
+}
+
+func (self *responseCache) set(key string, entry *cacheEntry) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if len(self.entries) >= self.cfg.MaxEntries {
+		now := self.now()
+		for k, e := range self.entries {
+			if now.After(e.expires) {
+				delete(self.entries, k)
+			}
+		}
+	}
+	// Still full so drop any entry.
+	for k := range self.entries {
+		if len(self.entries) < self.cfg.MaxEntries {
+			break
+		}
+		delete(self.entries, k)
+	}
+	self.entries[key] = entry
+}
+
+// invalidate drops the responses of the endpoints, all responses without endpoints.
+func (self *responseCache) invalidate(endpoints ...string) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	for k, e := range self.entries {
+		if len(endpoints) == 0 || contains(endpoints, e.endpoint) {
+			delete(self.entries, k)
+		}
+	}
+}
+
+// invalidateAddresses drops the address responses of the addresses,
+// requested with their 0x or io1 address in any case.
+func (self *responseCache) invalidateAddresses(addrs ...string) {
+	names := make(map[string]bool)
+	for _, a := range addrs {
+		if !common.IsHexAddress(a) {
+			continue
+		}
+		addr := common.HexToAddress(a)
+		names[strings.ToLower(addr.Hex())] = true
+		names[strings.ToLower(format.IoTeXAddress(addr))] = true
+	}
+
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	for k, e := range self.entries {
+		if e.endpoint != "address" {
+			continue
+		}
+		path := strings.SplitN(k, "?", 2)[0]
+		if names[strings.ToLower(path[strings.LastIndex(path, "/")+1:])] {
+			delete(self.entries, k)
+		}
+	}
+}
+
+// watch invalidates the cached responses when new data is committed to the store.
+func (self *responseCache) watch(ctx context.Context, hub *bridge.Hub) {
+	if self.cfg.MaxEntries == 0 {
+		return
+	}
+	for {
+		_, events, _, cancel := hub.Subscribe(0)
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				cancel()
+				return
+			case e, ok := <-events:
+				if !ok {
+					// Fell behind so some events were missed.
+					self.invalidate()
+					break loop
+				}
+				self.invalidate(cacheInvalidations[e.Type]...)
+				if e.Transfer != nil {
+					self.invalidateAddresses(e.Transfer.From, e.Transfer.To)
+				}
+			}
+		}
+		cancel()
+	}
+}
+
+func etag(body []byte) string {
+	h := fnv.New64a()
+	h.Write(body)
+	return `"` + strconv.FormatUint(h.Sum64(), 16) + `"`
+}
+
+func etagMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRecorder keeps the response in memory so it can be cached.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (self *responseRecorder) Header() http.Header {
+	return self.header
+}
+
+func (self *responseRecorder) WriteHeader(status int) {
+	self.status = status
+}
+
+func (self *responseRecorder) Write(b []byte) (int, error) {
+	return self.body.Write(b)
+}
@@ -0,0 +1,194 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func newTestCache(maxEntries int, now *time.Time) *responseCache {
+	cache := newResponseCache(CacheConfig{
+		MaxEntries: maxEntries,
+		TTLs: map[string]format.Duration{
+			"tvl":     {Duration: time.Minute},
+			"address": {Duration: time.Minute},
+		},
+	})
+	cache.now = func() time.Time { return *now }
+	return cache
+}
+
+func TestResponseCacheETag(t *testing.T) {
+	body := `{"status":"success"}`
+	tag := etag([]byte(body))
+	cases := []struct {
+		name        string
+		status      int
+		ifNoneMatch string
+		// expected status of the second request and the calls of the handler.
+		expected int
+		calls    int
+	}{
+		{name: "cached without a tag", status: http.StatusOK, expected: http.StatusOK, calls: 1},
+		{name: "matching tag", status: http.StatusOK, ifNoneMatch: tag, expected: http.StatusNotModified, calls: 1},
+		{name: "weak tag in a list", status: http.StatusOK, ifNoneMatch: `"other", W/` + tag, expected: http.StatusNotModified, calls: 1},
+		{name: "any tag", status: http.StatusOK, ifNoneMatch: "*", expected: http.StatusNotModified, calls: 1},
+		{name: "other tag", status: http.StatusOK, ifNoneMatch: `"other"`, expected: http.StatusOK, calls: 1},
+		{name: "errors aren't cached", status: http.StatusBadRequest, ifNoneMatch: tag, expected: http.StatusBadRequest, calls: 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+			calls := 0
+			handler := newTestCache(10, &now).wrap("tvl", func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(c.status)
+				w.Write([]byte(body))
+			})
+
+			first := httptest.NewRecorder()
+			handler(first, httptest.NewRequest(http.MethodGet, "/api/v1/tvl?b=1&a=2", nil))
+			if first.Code != c.status || first.Header().Get("ETag") != tag || first.Body.String() != body {
+				t.Fatalf("unexpected first response status:%v etag:%v body:%v", first.Code, first.Header().Get("ETag"), first.Body.String())
+			}
+
+			// The same parameters in another order hit the same entry.
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/tvl?a=2&b=1", nil)
+			if c.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", c.ifNoneMatch)
+			}
+			second := httptest.NewRecorder()
+			handler(second, req)
+			if second.Code != c.expected {
+				t.Fatalf("unexpected status:%v, expected:%v", second.Code, c.expected)
+			}
+			if second.Code == http.StatusNotModified && second.Body.Len() != 0 {
+				t.Fatalf("not modified response with a body:%v", second.Body.String())
+			}
+			if calls != c.calls {
+				t.Fatalf("unexpected handler calls:%v, expected:%v", calls, c.calls)
+			}
+		})
+	}
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	addr := common.HexToAddress("0x5a0b54d5dc17e0aadc383d2db43b0a0d3e029c4c")
+	entry := func(endpoint string, expires time.Time) *cacheEntry {
+		return &cacheEntry{endpoint: endpoint, status: http.StatusOK, expires: expires}
+	}
+	cases := []struct {
+		name       string
+		maxEntries int
+		entries    map[string]*cacheEntry
+		// apply runs at the given time after the entries are set.
+		after    time.Duration
+		apply    func(cache *responseCache)
+		expected []string
+	}{
+		{
+			name:       "expired entries are dropped on get",
+			maxEntries: 10,
+			entries: map[string]*cacheEntry{
+				"a": entry("tvl", start.Add(time.Minute)),
+				"b": entry("tvl", start.Add(time.Hour)),
+			},
+			after:    2 * time.Minute,
+			expected: []string{"b"},
+		},
+		{
+			name:       "full cache drops the expired entries first",
+			maxEntries: 2,
+			entries: map[string]*cacheEntry{
+				"a": entry("tvl", start.Add(time.Minute)),
+				"b": entry("tvl", start.Add(time.Hour)),
+			},
+			after:    2 * time.Minute,
+			apply:    func(cache *responseCache) { cache.set("c", entry("tvl", start.Add(time.Hour))) },
+			expected: []string{"b", "c"},
+		},
+		{
+			name:       "full cache without expired entries stays at the max",
+			maxEntries: 2,
+			entries: map[string]*cacheEntry{
+				"a": entry("tvl", start.Add(time.Hour)),
+				"b": entry("tvl", start.Add(time.Hour)),
+			},
+			apply:    func(cache *responseCache) { cache.set("c", entry("tvl", start.Add(time.Hour))) },
+			expected: []string{"c", "?"},
+		},
+		{
+			name:       "invalidate the endpoint",
+			maxEntries: 10,
+			entries: map[string]*cacheEntry{
+				"/api/v1/tvl?":    entry("tvl", start.Add(time.Hour)),
+				"/api/v1/volume?": entry("volume", start.Add(time.Hour)),
+			},
+			apply:    func(cache *responseCache) { cache.invalidate("tvl") },
+			expected: []string{"/api/v1/volume?"},
+		},
+		{
+			name:       "invalidate all",
+			maxEntries: 10,
+			entries: map[string]*cacheEntry{
+				"/api/v1/tvl?":    entry("tvl", start.Add(time.Hour)),
+				"/api/v1/volume?": entry("volume", start.Add(time.Hour)),
+			},
+			apply:    func(cache *responseCache) { cache.invalidate() },
+			expected: []string{},
+		},
+		{
+			name:       "invalidate the address in any form",
+			maxEntries: 10,
+			entries: map[string]*cacheEntry{
+				"/api/v1/address/" + addr.Hex() + "?":                         entry("address", start.Add(time.Hour)),
+				"/api/v1/address/" + strings.ToLower(addr.Hex()) + "?limit=5": entry("address", start.Add(time.Hour)),
+				"/api/v1/address/" + format.IoTeXAddress(addr) + "?":          entry("address", start.Add(time.Hour)),
+				"/api/v1/address/0x0000000000000000000000000000000000000001?": entry("address", start.Add(time.Hour)),
+				"/api/v1/tvl?": entry("tvl", start.Add(time.Hour)),
+			},
+			apply: func(cache *responseCache) { cache.invalidateAddresses(strings.ToLower(addr.Hex())) },
+			expected: []string{
+				"/api/v1/address/0x0000000000000000000000000000000000000001?",
+				"/api/v1/tvl?",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			now := start
+			cache := newTestCache(c.maxEntries, &now)
+			for k, e := range c.entries {
+				cache.entries[k] = e
+			}
+			now = start.Add(c.after)
+			if c.apply != nil {
+				c.apply(cache)
+			}
+			for k := range c.entries {
+				cache.get(k)
+			}
+			if len(cache.entries) != len(c.expected) {
+				t.Fatalf("unexpected entries count:%v, expected:%v", len(cache.entries), len(c.expected))
+			}
+			for _, k := range c.expected {
+				// Any of the entries may be dropped when none is expired.
+				if k == "?" {
+					continue
+				}
+				if _, ok := cache.entries[k]; !ok {
+					t.Fatalf("missing entry:%v", k)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/export"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/route"
+)
+
+// export streams the transfers, tvl snapshots or prices as csv or json lines.
+// The response isn't buffered so errors after the first row abort it,
+// the client sees a broken connection instead of a complete file.
+func (api *API) export(w http.ResponseWriter, r *http.Request) {
+	kind := route.Param(r.Context(), "kind")
+	params := r.URL.Query()
+	format := params.Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if err := export.Validate(kind, format); err != nil {
+		api.respondError(w, &apiError{errorBadData, err}, nil)
+		return
+	}
+	q, errRes := parseQuery(r)
+	if errRes != nil {
+		api.respondError(w, errRes.err, nil)
+		return
+	}
+	if err := export.ValidateQuery(kind, q); err != nil {
+		api.respondError(w, &apiError{errorBadData, err}, nil)
+		return
+	}
+
+	filename := kind + "." + format
+	var (
+		out io.Writer = w
+		gz  *gzip.Writer
+	)
+	if params.Get("gzip") == "true" {
+		filename += ".gz"
+		gz = gzip.NewWriter(w)
+		out = gz
+		w.Header().Set("Content-Type", "application/gzip")
+	} else {
+		w.Header().Set("Content-Type", export.ContentType(format))
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	rows, err := export.Write(r.Context(), api.store, out, kind, format, q)
+	if err != nil {
+		level.Error(api.logger).Log("msg", "exporting", "kind", kind, "rows", rows, "err", errors.Cause(err))
+		// Closing the gzip writer or ending the response normally would make a partial export look complete.
+		panic(http.ErrAbortHandler)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			level.Error(api.logger).Log("msg", "closing gzip writer", "kind", kind, "err", err)
+			panic(http.ErrAbortHandler)
+		}
+	}
+	level.Debug(api.logger).Log("msg", "exported", "kind", kind, "rows", rows)
+}
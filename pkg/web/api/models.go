@@ -0,0 +1,138 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import "time"
+
+// ModelVersion is increased on every breaking change of the response models.
+const ModelVersion = 1
+
+type VolumeData struct {
+	Version  int            `json:"version"`
+	Interval string         `json:"interval"`
+	Series   []VolumeSeries `json:"series"`
+}
+
+type VolumeSeries struct {
+	Bridge string        `json:"bridge"`
+	Side   string        `json:"side"`
+	Symbol string        `json:"symbol"`
+	Points []VolumePoint `json:"points"`
+}
+
+type VolumePoint struct {
+	Time      time.Time `json:"time"`
+	Amount    float64   `json:"amount"`
+	AmountUSD float64   `json:"amountUsd"`
+	Count     int64     `json:"count"`
+}
+
+type TVLData struct {
+	Version  int         `json:"version"`
+	Interval string      `json:"interval"`
+	Series   []TVLSeries `json:"series"`
+}
+
+type TVLSeries struct {
+	Network string     `json:"network"`
+	Symbol  string     `json:"symbol"`
+	Points  []TVLPoint `json:"points"`
+}
+
+type TVLPoint struct {
+	Time     time.Time `json:"time"`
+	Value    float64   `json:"value"`
+	ValueUSD float64   `json:"valueUsd"`
+}
+
+type PriceData struct {
+	Version  int           `json:"version"`
+	Interval string        `json:"interval"`
+	Series   []PriceSeries `json:"series"`
+}
+
+type PriceSeries struct {
+	Symbol string       `json:"symbol"`
+	Points []PricePoint `json:"points"`
+}
+
+type PricePoint struct {
+	Time  time.Time `json:"time"`
+	Price float64   `json:"price"`
+}
+
+type TransfersData struct {
+	Version   int        `json:"version"`
+	Transfers []Transfer `json:"transfers"`
+}
+
+type Transfer struct {
+	Time        time.Time `json:"time"`
+	Bridge      string    `json:"bridge"`
+	Side        string    `json:"side"`
+	Symbol      string    `json:"symbol"`
+	From        string    `json:"from"`
+	Amount      float64   `json:"amount"`
+	AmountUSD   float64   `json:"amountUsd"`
+	To          string    `json:"to,omitempty"`
+	Hash        string    `json:"hash,omitempty"`
+	Token       string    `json:"token,omitempty"`
+	DepositID   string    `json:"depositId,omitempty"`
+	BlockNumber uint64    `json:"blockNumber,omitempty"`
+	// Status is the cross-chain status, only the deposits are tracked for now.
+	Status string `json:"status"`
+}
+
+type TransfersPage struct {
+	Version   int        `json:"version"`
+	Transfers []Transfer `json:"transfers"`
+	// NextCursor fetches the next page, empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type AddressProfile struct {
+	Version   int    `json:"version"`
+	Address   string `json:"address"`
+	IoAddress string `json:"ioAddress"`
+	// Deposits are the transfers sent by the address and Settlements the ones received.
+	Deposits       int64          `json:"deposits"`
+	Settlements    int64          `json:"settlements"`
+	FirstActivity  *time.Time     `json:"firstActivity,omitempty"`
+	LastActivity   *time.Time     `json:"lastActivity,omitempty"`
+	Tokens         []AddressTotal `json:"tokens"`
+	Bridges        []AddressTotal `json:"bridges"`
+	Counterparties []AddressTotal `json:"counterparties"`
+	// Transfers of the address, latest first.
+	Transfers []Transfer `json:"transfers"`
+	// NextCursor fetches the next page of transfers, empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// AddressTotal is the activity of an address grouped by token, bridge or counterparty.
+type AddressTotal struct {
+	Name        string  `json:"name"`
+	Deposits    int64   `json:"deposits"`
+	Settlements int64   `json:"settlements"`
+	Sent        float64 `json:"sent"`
+	SentUSD     float64 `json:"sentUsd"`
+	Received    float64 `json:"received"`
+	ReceivedUSD float64 `json:"receivedUsd"`
+}
+
+type StreamTVL struct {
+	Time     time.Time `json:"time"`
+	Network  string    `json:"network"`
+	Symbol   string    `json:"symbol"`
+	Token    string    `json:"token,omitempty"`
+	Value    float64   `json:"value"`
+	ValueUSD float64   `json:"valueUsd"`
+}
+
+type StreamPrice struct {
+	Time       time.Time `json:"time"`
+	Symbol     string    `json:"symbol"`
+	Source     string    `json:"source"`
+	Price      float64   `json:"price"`
+	AgeSeconds float64   `json:"ageSeconds"`
+}
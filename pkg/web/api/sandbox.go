@@ -0,0 +1,237 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/pkg/errors"
+)
+
+// QueryAdminKeyEnv is the env var with the key that enables the query endpoint
+// for the callers that send it in the X-Admin-Key header.
+const QueryAdminKeyEnv = "QUERY_ADMIN_KEY"
+
+// QueryTokenEnv is the env var with the influxdb token of the query endpoint.
+// It needs to be a read-only token of the allowed buckets, the endpoint is disabled without it.
+const QueryTokenEnv = "INFLUXDB_QUERY_TOKEN"
+
+// QueryConfig restricts the query endpoint.
+type QueryConfig struct {
+	// Enabled opens the endpoint to everyone,
+	// otherwise it is only available with the admin key.
+	Enabled bool
+	// Buckets and Measurements that the queries are allowed to read.
+	Buckets      []string
+	Measurements []string
+	// MaxRange is the max time range of every queried stream.
+	MaxRange format.Duration
+	// MaxRows is the max number of returned rows.
+	MaxRows int
+	Timeout format.Duration
+}
+
+var (
+	fluxName         = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+	fluxDurationLit  = regexp.MustCompile(`^(-?)((?:\d+(?:mo|ms|us|ns|y|w|d|h|m|s))+)$`)
+	fluxDurationPart = regexp.MustCompile(`(\d+)(mo|ms|us|ns|y|w|d|h|m|s)`)
+)
+
+// fluxAggregates are the functions that the queries can aggregate with.
+var fluxAggregates = []string{"count", "first", "last", "max", "mean", "median", "min", "sum"}
+
+var fluxUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+	"mo": 30 * 24 * time.Hour,
+	"y":  365 * 24 * time.Hour,
+}
+
+// authorizeQuery checks that the query endpoint has its token and is enabled for the caller.
+func (api *API) authorizeQuery(r *http.Request) *apiError {
+	if api.queryAPI == nil {
+		return &apiError{errorNotFound, errors.Errorf("the query endpoint needs the %v env var", QueryTokenEnv)}
+	}
+	if api.queryCfg.Enabled {
+		return nil
+	}
+	key := os.Getenv(QueryAdminKeyEnv)
+	given := r.Header.Get("X-Admin-Key")
+	if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(given)) == 1 {
+		return nil
+	}
+	return &apiError{errorNotFound, errors.New("the query endpoint is disabled")}
+}
+
+// Selection is the body of the query endpoint.
+// The flux query is built from it by the server so the callers can't run any other flux.
+type Selection struct {
+	Bucket      string `json:"bucket"`
+	Measurement string `json:"measurement"`
+	// Start and Stop are times or durations relative to now like -7d, Stop defaults to now.
+	Start string `json:"start"`
+	Stop  string `json:"stop"`
+	// Fields to read, all when empty.
+	Fields []string `json:"fields"`
+	// Map: tag -> value that the points need to have.
+	Tags map[string]string `json:"tags"`
+	// Group by these tags, all points are in one group when set but empty.
+	Group []string `json:"group"`
+	// Aggregate the points with this function, in windows of Every when set.
+	Aggregate string `json:"aggregate"`
+	Every     string `json:"every"`
+}
+
+// sandboxQuery returns the flux query of the selection.
+// It only reads the allowed buckets and measurements within the max time range,
+// and all the values from the caller are quoted or parsed so they can't change the query.
+func (api *API) sandboxQuery(sel Selection, now time.Time) (string, error) {
+	if !contains(api.queryCfg.Buckets, sel.Bucket) {
+		return "", errors.Errorf("bucket:%v is not allowed", sel.Bucket)
+	}
+	if !contains(api.queryCfg.Measurements, sel.Measurement) {
+		return "", errors.Errorf("measurement:%v is not allowed", sel.Measurement)
+	}
+
+	if sel.Start == "" {
+		return "", errors.New("the query needs a start")
+	}
+	start, err := parseFluxTime(sel.Start, now)
+	if err != nil {
+		return "", errors.Wrap(err, "start")
+	}
+	stop := now
+	if sel.Stop != "" {
+		if stop, err = parseFluxTime(sel.Stop, now); err != nil {
+			return "", errors.Wrap(err, "stop")
+		}
+	}
+	if !start.Before(stop) {
+		return "", errors.New("start needs to be before stop")
+	}
+	if stop.Sub(start) > api.queryCfg.MaxRange.Duration {
+		return "", errors.Errorf("range:%v is longer than the max:%v", stop.Sub(start), api.queryCfg.MaxRange.Duration)
+	}
+
+	query := `from(bucket: ` + bridge.FluxString(sel.Bucket) + `)
+	|> range(start: ` + start.UTC().Format(time.RFC3339Nano) + `, stop: ` + stop.UTC().Format(time.RFC3339Nano) + `)
+	|> filter(fn: (r) => r["_measurement"] == ` + bridge.FluxString(sel.Measurement) + `)`
+
+	tags := make([]string, 0, len(sel.Tags))
+	for tag := range sel.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		if !fluxName.MatchString(tag) {
+			return "", errors.Errorf("invalid tag:%q", tag)
+		}
+		query += `
+	|> filter(fn: (r) => r[` + bridge.FluxString(tag) + `] == ` + bridge.FluxString(sel.Tags[tag]) + `)`
+	}
+
+	if len(sel.Fields) > 0 {
+		fields := make([]string, len(sel.Fields))
+		for i, field := range sel.Fields {
+			if !fluxName.MatchString(field) {
+				return "", errors.Errorf("invalid field:%q", field)
+			}
+			fields[i] = `r["_field"] == ` + bridge.FluxString(field)
+		}
+		query += `
+	|> filter(fn: (r) => ` + strings.Join(fields, " or ") + `)`
+	}
+
+	if sel.Group != nil {
+		columns := make([]string, len(sel.Group))
+		for i, tag := range sel.Group {
+			if !fluxName.MatchString(tag) {
+				return "", errors.Errorf("invalid group tag:%q", tag)
+			}
+			columns[i] = bridge.FluxString(tag)
+		}
+		query += `
+	|> group(columns: [` + strings.Join(columns, ", ") + `])`
+	}
+
+	if sel.Every != "" && sel.Aggregate == "" {
+		return "", errors.New("every needs an aggregate")
+	}
+	if sel.Aggregate != "" {
+		if !contains(fluxAggregates, sel.Aggregate) {
+			return "", errors.Errorf("invalid aggregate:%v, supported are %v", sel.Aggregate, strings.Join(fluxAggregates, ", "))
+		}
+		if sel.Every == "" {
+			query += `
+	|> ` + sel.Aggregate + `()`
+		} else {
+			m := fluxDurationLit.FindStringSubmatch(sel.Every)
+			if m == nil || m[1] == "-" {
+				return "", errors.Errorf("invalid every:%v", sel.Every)
+			}
+			every := parseFluxDuration(m[2])
+			if every < time.Second {
+				return "", errors.Errorf("every:%v is shorter than a second", sel.Every)
+			}
+			query += `
+	|> aggregateWindow(every: ` + strconv.FormatInt(int64(every/time.Second), 10) + `s, fn: ` + sel.Aggregate + `, createEmpty: false)`
+		}
+	}
+	return query, nil
+}
+
+// parseFluxTime parses flux time literals, relative durations like -7d and now().
+func parseFluxTime(v string, now time.Time) (time.Time, error) {
+	if v == "now()" {
+		return now, nil
+	}
+	if m := fluxDurationLit.FindStringSubmatch(v); m != nil {
+		d := parseFluxDuration(m[2])
+		if m[1] == "-" {
+			d = -d
+		}
+		return now.Add(d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.Errorf("only duration and time literals are allowed, got:%v", v)
+}
+
+// parseFluxDuration returns the duration of a literal matched by fluxDurationLit.
+func parseFluxDuration(v string) time.Duration {
+	var d time.Duration
+	for _, part := range fluxDurationPart.FindAllStringSubmatch(v, -1) {
+		n, _ := strconv.ParseInt(part[1], 10, 64)
+		d += time.Duration(n) * fluxUnits[part[2]]
+	}
+	return d
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+)
+
+func TestSandboxQuery(t *testing.T) {
+	api := &API{queryCfg: QueryConfig{
+		Buckets:      []string{"my-bucket"},
+		Measurements: []string{"tx", "tvl"},
+		MaxRange:     format.Duration{Duration: 30 * 24 * time.Hour},
+	}}
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name  string
+		sel   Selection
+		query string
+		err   string
+	}{
+		{
+			name: "range and measurement",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-7d"},
+			query: `from(bucket: "my-bucket")
+	|> range(start: 2021-05-25T00:00:00Z, stop: 2021-06-01T00:00:00Z)
+	|> filter(fn: (r) => r["_measurement"] == "tx")`,
+		},
+		{
+			name: "tags fields group and windows",
+			sel: Selection{
+				Bucket:      "my-bucket",
+				Measurement: "tx",
+				Start:       "2021-05-01T00:00:00Z",
+				Stop:        "-1d",
+				Fields:      []string{"amount", "amount_usd"},
+				Tags:        map[string]string{"symbol": "WETH", "bridge": "ethiotex"},
+				Group:       []string{"symbol"},
+				Aggregate:   "sum",
+				Every:       "1d",
+			},
+			query: `from(bucket: "my-bucket")
+	|> range(start: 2021-05-01T00:00:00Z, stop: 2021-05-31T00:00:00Z)
+	|> filter(fn: (r) => r["_measurement"] == "tx")
+	|> filter(fn: (r) => r["bridge"] == "ethiotex")
+	|> filter(fn: (r) => r["symbol"] == "WETH")
+	|> filter(fn: (r) => r["_field"] == "amount" or r["_field"] == "amount_usd")
+	|> group(columns: ["symbol"])
+	|> aggregateWindow(every: 86400s, fn: sum, createEmpty: false)`,
+		},
+		{
+			name: "aggregate of one group",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tvl", Start: "-1h", Group: []string{}, Aggregate: "count"},
+			query: `from(bucket: "my-bucket")
+	|> range(start: 2021-05-31T23:00:00Z, stop: 2021-06-01T00:00:00Z)
+	|> filter(fn: (r) => r["_measurement"] == "tvl")
+	|> group(columns: [])
+	|> count()`,
+		},
+		{
+			name: "tag values are quoted",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-1h", Tags: map[string]string{"symbol": `x") |> to(bucket: "my-bucket") //${v}`}},
+			query: `from(bucket: "my-bucket")
+	|> range(start: 2021-05-31T23:00:00Z, stop: 2021-06-01T00:00:00Z)
+	|> filter(fn: (r) => r["_measurement"] == "tx")
+	|> filter(fn: (r) => r["symbol"] == "x\") |> to(bucket: \"my-bucket\") //\${v}")`,
+		},
+		{
+			// The aliasing bypass of the raw flux sandbox: src = from, w = to.
+			name: "aliased functions",
+			sel:  Selection{Bucket: `_monitoring") src = from w = to src(bucket: "_monitoring`, Measurement: "tx", Start: "-1h"},
+			err:  "is not allowed",
+		},
+		{
+			name: "bucket not allowed",
+			sel:  Selection{Bucket: "_monitoring", Measurement: "tx", Start: "-1h"},
+			err:  "bucket:_monitoring is not allowed",
+		},
+		{
+			name: "measurement not allowed",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "price_quarantine", Start: "-1h"},
+			err:  "measurement:price_quarantine is not allowed",
+		},
+		{
+			name: "no start",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx"},
+			err:  "needs a start",
+		},
+		{
+			name: "flux expression as start",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-1h) |> to(bucket: \"x\""},
+			err:  "only duration and time literals are allowed",
+		},
+		{
+			name: "stop before start",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-1h", Stop: "-2h"},
+			err:  "start needs to be before stop",
+		},
+		{
+			name: "range too long",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-31d"},
+			err:  "is longer than the max",
+		},
+		{
+			name: "invalid tag",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-1h", Tags: map[string]string{`a"]`: "x"}},
+			err:  "invalid tag",
+		},
+		{
+			name: "invalid field",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-1h", Fields: []string{"amount or true"}},
+			err:  "invalid field",
+		},
+		{
+			name: "invalid group tag",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-1h", Group: []string{"a-b"}},
+			err:  "invalid group tag",
+		},
+		{
+			name: "aggregate not allowed",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-1h", Aggregate: "to"},
+			err:  "invalid aggregate",
+		},
+		{
+			name: "every without aggregate",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-1h", Every: "1h"},
+			err:  "every needs an aggregate",
+		},
+		{
+			name: "negative every",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-1h", Aggregate: "sum", Every: "-1h"},
+			err:  "invalid every",
+		},
+		{
+			name: "every below a second",
+			sel:  Selection{Bucket: "my-bucket", Measurement: "tx", Start: "-1h", Aggregate: "sum", Every: "10ms"},
+			err:  "shorter than a second",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			query, err := api.sandboxQuery(c.sel, now)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected error containing %q, got:%v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error:%v", err)
+			}
+			if query != c.query {
+				t.Fatalf("unexpected query:\n%v\nexpected:\n%v", query, c.query)
+			}
+		})
+	}
+}
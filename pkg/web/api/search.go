@@ -0,0 +1,139 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// transferDeposited is the status of a transfer deposited in the token cashier.
+const transferDeposited = "deposited"
+
+var (
+	validHash      = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+	validDepositID = regexp.MustCompile(`^[0-9]{1,78}$`)
+)
+
+// searchTransfers finds the transfers by tx hash, deposit id, sender, recipient or token.
+// The results are paginated with the cursor returned in the previous page.
+func (api *API) searchTransfers(r *http.Request) apiFuncResult {
+	q, errRes := parseQuery(r)
+	if errRes != nil {
+		return *errRes
+	}
+	params := r.URL.Query()
+	// Search all the history unless a start is given.
+	if params.Get("from") == "" {
+		q.From = time.Unix(0, 0)
+	}
+	s := types.TransferSearch{
+		Query:     q,
+		Hash:      params.Get("hash"),
+		DepositID: params.Get("id"),
+		Sender:    params.Get("sender"),
+		Recipient: params.Get("recipient"),
+		Token:     params.Get("token"),
+	}
+	if s.Hash != "" && !validHash.MatchString(s.Hash) {
+		return invalidParamError(errors.New("invalid tx hash"), "hash")
+	}
+	if s.DepositID != "" && !validDepositID.MatchString(s.DepositID) {
+		return invalidParamError(errors.New("invalid deposit id"), "id")
+	}
+	for _, p := range []struct {
+		name string
+		dst  *string
+	}{
+		{"sender", &s.Sender},
+		{"recipient", &s.Recipient},
+	} {
+		if *p.dst == "" {
+			continue
+		}
+		addr, err := format.ParseAddress(*p.dst)
+		if err != nil {
+			return invalidParamError(err, p.name)
+		}
+		*p.dst = addr.Hex()
+	}
+	if s.Token != "" && !common.IsHexAddress(s.Token) && !validName.MatchString(s.Token) {
+		return invalidParamError(errors.New("invalid token symbol or address"), "token")
+	}
+
+	switch params.Get("order") {
+	case "", "desc":
+	case "asc":
+		s.Ascending = true
+	default:
+		return invalidParamError(errors.New("order needs to be asc or desc"), "order")
+	}
+	if v := params.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return invalidParamError(err, "cursor")
+		}
+		s.Cursor = cursor
+	}
+
+	transfers, next, err := api.searchPage(r.Context(), s)
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorExec, err}}
+	}
+	return apiFuncResult{&TransfersPage{Version: ModelVersion, Transfers: transfers, NextCursor: next}, nil}
+}
+
+// searchPage returns a page of the search results and the cursor of the next page, empty on the last page.
+func (api *API) searchPage(ctx context.Context, s types.TransferSearch) ([]Transfer, string, error) {
+	// Fetch one more to know if there is a next page.
+	limit := s.Limit
+	s.Limit++
+	txs, err := api.store.SearchTransfers(ctx, s)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(txs) > limit {
+		txs = txs[:limit]
+		last := txs[len(txs)-1]
+		next = encodeCursor(types.Cursor{Time: last.Time(), Hash: last.Hash, DepositID: last.DepositID})
+	}
+	transfers := make([]Transfer, 0, len(txs))
+	for _, tx := range txs {
+		transfers = append(transfers, toTransfer(tx))
+	}
+	return transfers, next, nil
+}
+
+// encodeCursor returns an opaque cursor, the unix time in nanoseconds, the tx hash and the receipt id.
+func encodeCursor(c types.Cursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.Time.UnixNano(), 10) + ":" + c.Hash + ":" + c.DepositID))
+}
+
+func decodeCursor(v string) (*types.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding cursor")
+	}
+	parts := strings.Split(string(b), ":")
+	if len(parts) != 3 || (parts[1] != "" && !validHash.MatchString(parts[1])) || (parts[2] != "" && !validDepositID.MatchString(parts[2])) {
+		return nil, errors.New("invalid cursor")
+	}
+	ns, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cursor time")
+	}
+	return &types.Cursor{Time: time.Unix(0, ns), Hash: parts[1], DepositID: parts[2]}, nil
+}
@@ -0,0 +1,59 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	hash := "0x" + "ab01ab01ab01ab01ab01ab01ab01ab01ab01ab01ab01ab01ab01ab01ab01ab01"
+	cases := []struct {
+		name   string
+		cursor types.Cursor
+	}{
+		{"full", types.Cursor{Time: time.Unix(1622505600, 123456789), Hash: hash, DepositID: "42"}},
+		{"time only", types.Cursor{Time: time.Unix(1622505600, 0)}},
+		{"before epoch", types.Cursor{Time: time.Unix(-1, 0), Hash: hash}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := decodeCursor(encodeCursor(c.cursor))
+			if err != nil {
+				t.Fatalf("decoding cursor:%v", err)
+			}
+			if !got.Time.Equal(c.cursor.Time) || got.Hash != c.cursor.Hash || got.DepositID != c.cursor.DepositID {
+				t.Fatalf("unexpected cursor:%+v, expected:%+v", *got, c.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	cases := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("1:0x:1"))},
+		{"missing parts", encode("1622505600000000000:")},
+		{"extra parts", encode("1622505600000000000:::")},
+		{"invalid hash", encode("1622505600000000000:0x1234:")},
+		{"invalid deposit id", encode("1622505600000000000::-1")},
+		{"invalid time", encode("yesterday::")},
+		{"empty", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got, err := decodeCursor(c.value); err == nil {
+				t.Fatalf("unexpected cursor:%+v, expected an error", *got)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultRange     = 30 * 24 * time.Hour
+	defaultInterval  = 24 * time.Hour
+	minInterval      = time.Minute
+	maxPoints        = 10000
+	defaultTransfers = 100
+	maxTransfers     = 1000
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// parseQuery parses the common series parameters: from, to, interval, bridge, side, network, symbol and limit.
+func parseQuery(r *http.Request) (types.Query, *apiFuncResult) {
+	q := types.Query{
+		To:       time.Now(),
+		Interval: defaultInterval,
+		Limit:    defaultTransfers,
+	}
+	params := r.URL.Query()
+
+	if v := params.Get("to"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			res := invalidParamError(err, "to")
+			return q, &res
+		}
+		q.To = t
+	}
+	q.From = q.To.Add(-defaultRange)
+	if v := params.Get("from"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			res := invalidParamError(err, "from")
+			return q, &res
+		}
+		q.From = t
+	}
+	if !q.From.Before(q.To) {
+		res := invalidParamError(errors.New("from must be before to"), "from")
+		return q, &res
+	}
+
+	if v := params.Get("interval"); v != "" {
+		d, err := parseInterval(v)
+		if err != nil {
+			res := invalidParamError(err, "interval")
+			return q, &res
+		}
+		q.Interval = d
+	}
+	if q.Interval < minInterval {
+		res := invalidParamError(errors.Errorf("min interval is %v", minInterval), "interval")
+		return q, &res
+	}
+	if q.To.Sub(q.From)/q.Interval > maxPoints {
+		res := invalidParamError(errors.Errorf("more than %v points, use a bigger interval", maxPoints), "interval")
+		return q, &res
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *string
+	}{
+		{"bridge", (*string)(&q.Bridge)},
+		{"side", (*string)(&q.Side)},
+		{"network", (*string)(&q.Network)},
+		{"symbol", &q.Symbol},
+	} {
+		v := params.Get(p.name)
+		if v == "" {
+			continue
+		}
+		if !validName.MatchString(v) {
+			res := invalidParamError(errors.Errorf("invalid value:%v", v), p.name)
+			return q, &res
+		}
+		*p.dst = v
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxTransfers {
+			res := invalidParamError(errors.Errorf("limit needs to be between 1 and %v", maxTransfers), "limit")
+			return q, &res
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
+
+// parseTimeParam accepts unix seconds or RFC3339 times.
+func parseTimeParam(v string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// parseInterval accepts go durations and a number of days like 7d.
+func parseInterval(v string) (time.Duration, error) {
+	if strings.HasSuffix(v, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(v, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(v)
+}
+
+func (api *API) volume(r *http.Request) apiFuncResult {
+	q, errRes := parseQuery(r)
+	if errRes != nil {
+		return *errRes
+	}
+	points, err := api.store.Volume(r.Context(), q)
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorExec, err}}
+	}
+
+	data := &VolumeData{Version: ModelVersion, Interval: q.Interval.String(), Series: []VolumeSeries{}}
+	index := make(map[string]int)
+	for _, p := range points {
+		key := string(p.Bridge) + "/" + string(p.BridgeSide) + "/" + p.Symbol
+		i, ok := index[key]
+		if !ok {
+			i = len(data.Series)
+			index[key] = i
+			data.Series = append(data.Series, VolumeSeries{
+				Bridge: string(p.Bridge),
+				Side:   string(p.BridgeSide),
+				Symbol: p.Symbol,
+			})
+		}
+		data.Series[i].Points = append(data.Series[i].Points, VolumePoint{
+			Time:      p.Time,
+			Amount:    p.Amount,
+			AmountUSD: p.AmountUSD,
+			Count:     p.Count,
+		})
+	}
+	return apiFuncResult{data, nil}
+}
+
+func (api *API) tvl(r *http.Request) apiFuncResult {
+	q, errRes := parseQuery(r)
+	if errRes != nil {
+		return *errRes
+	}
+	points, err := api.store.TVL(r.Context(), q)
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorExec, err}}
+	}
+
+	data := &TVLData{Version: ModelVersion, Interval: q.Interval.String(), Series: []TVLSeries{}}
+	index := make(map[string]int)
+	for _, p := range points {
+		key := string(p.Network) + "/" + p.Symbol
+		i, ok := index[key]
+		if !ok {
+			i = len(data.Series)
+			index[key] = i
+			data.Series = append(data.Series, TVLSeries{
+				Network: string(p.Network),
+				Symbol:  p.Symbol,
+			})
+		}
+		data.Series[i].Points = append(data.Series[i].Points, TVLPoint{
+			Time:     p.Time,
+			Value:    p.Value,
+			ValueUSD: p.ValueUSD,
+		})
+	}
+	return apiFuncResult{data, nil}
+}
+
+func (api *API) prices(r *http.Request) apiFuncResult {
+	q, errRes := parseQuery(r)
+	if errRes != nil {
+		return *errRes
+	}
+	points, err := api.store.Prices(r.Context(), q)
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorExec, err}}
+	}
+
+	data := &PriceData{Version: ModelVersion, Interval: q.Interval.String(), Series: []PriceSeries{}}
+	index := make(map[string]int)
+	for _, p := range points {
+		i, ok := index[p.Symbol]
+		if !ok {
+			i = len(data.Series)
+			index[p.Symbol] = i
+			data.Series = append(data.Series, PriceSeries{Symbol: p.Symbol})
+		}
+		data.Series[i].Points = append(data.Series[i].Points, PricePoint{
+			Time:  p.Time,
+			Price: p.Value,
+		})
+	}
+	return apiFuncResult{data, nil}
+}
+
+func (api *API) transfers(r *http.Request) apiFuncResult {
+	q, errRes := parseQuery(r)
+	if errRes != nil {
+		return *errRes
+	}
+	txs, err := api.store.Transfers(r.Context(), q)
+	if err != nil {
+		return apiFuncResult{nil, &apiError{errorExec, err}}
+	}
+
+	data := &TransfersData{Version: ModelVersion, Transfers: make([]Transfer, 0, len(txs))}
+	for _, tx := range txs {
+		data.Transfers = append(data.Transfers, toTransfer(tx))
+	}
+	return apiFuncResult{data, nil}
+}
+
+func toTransfer(tx types.Transaction) Transfer {
+	return Transfer{
+		Time:        time.Unix(int64(tx.Timestamp), 0).UTC(),
+		Bridge:      string(tx.Bridge),
+		Side:        string(tx.BridgeSide),
+		Symbol:      tx.Symbol,
+		From:        tx.From,
+		Amount:      tx.Amount,
+		AmountUSD:   tx.AmountUSD,
+		To:          tx.To,
+		Hash:        tx.Hash,
+		Token:       tx.Token,
+		DepositID:   tx.DepositID,
+		BlockNumber: tx.BlockNo,
+		Status:      transferDeposited,
+	}
+}
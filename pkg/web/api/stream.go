@@ -0,0 +1,214 @@
+// Copyright (c) The Tellor Authors.
+// Licensed under the MIT License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/types"
+	"github.com/go-kit/kit/log/level"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+// streamHeartbeat keeps idle connections open through proxies.
+const streamHeartbeat = 15 * time.Second
+
+// eventReset tells the client that some events were missed and it needs to reload its data.
+const eventReset = "reset"
+
+type streamFilter struct {
+	types   map[bridge.EventType]bool
+	bridge  types.Bridge
+	symbol  string
+	address string
+}
+
+// match returns true when the event passes all filters.
+// The bridge and address filters only match transfers.
+func (self streamFilter) match(e bridge.Event) bool {
+	if len(self.types) > 0 && !self.types[e.Type] {
+		return false
+	}
+	var symbol string
+	switch e.Type {
+	case bridge.EventTransfer:
+		if self.bridge != "" && e.Transfer.Bridge != self.bridge {
+			return false
+		}
+		if self.address != "" && !strings.EqualFold(e.Transfer.From, self.address) && !strings.EqualFold(e.Transfer.To, self.address) {
+			return false
+		}
+		symbol = e.Transfer.Symbol
+	case bridge.EventTVL:
+		if self.bridge != "" || self.address != "" {
+			return false
+		}
+		symbol = e.TVL.Symbol
+	case bridge.EventPrice:
+		if self.bridge != "" || self.address != "" {
+			return false
+		}
+		symbol = e.Price.Symbol
+	}
+	return self.symbol == "" || strings.EqualFold(symbol, self.symbol)
+}
+
+func parseStreamFilter(r *http.Request) (streamFilter, error) {
+	params := r.URL.Query()
+	f := streamFilter{types: make(map[bridge.EventType]bool)}
+	if v := params.Get("types"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			switch typ := bridge.EventType(t); typ {
+			case bridge.EventTransfer, bridge.EventTVL, bridge.EventPrice:
+				f.types[typ] = true
+			default:
+				return f, errors.Errorf("invalid event type:%v", t)
+			}
+		}
+	}
+	for name, v := range map[string]string{"bridge": params.Get("bridge"), "symbol": params.Get("symbol")} {
+		if v != "" && !validName.MatchString(v) {
+			return f, errors.Errorf("invalid %v:%v", name, v)
+		}
+	}
+	f.bridge = types.Bridge(params.Get("bridge"))
+	f.symbol = params.Get("symbol")
+	if v := params.Get("address"); v != "" {
+		addr, err := format.ParseAddress(v)
+		if err != nil {
+			return f, err
+		}
+		f.address = addr.Hex()
+	}
+	return f, nil
+}
+
+// stream pushes the new transfers, tvl and price updates as server-sent events.
+// Clients resume from the Last-Event-ID header, or the lastEventId parameter,
+// and get a reset event when some events are no longer available.
+func (api *API) stream(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStreamFilter(r)
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, err}, nil)
+		return
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	var (
+		epoch int64
+		since uint64
+	)
+	if lastID != "" {
+		epoch, since, err = parseEventID(lastID)
+		if err != nil {
+			api.respondError(w, &apiError{errorBadData, errors.Wrap(err, "invalid last event id")}, nil)
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		api.respondError(w, &apiError{errorInternal, errors.New("streaming not supported")}, nil)
+		return
+	}
+
+	backlog, events, complete, cancel := api.store.Events().Resume(epoch, since)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+
+	if !complete {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", eventReset)
+	}
+	for _, e := range backlog {
+		if err := api.writeEvent(w, filter, e); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-events:
+			if !ok {
+				// Fell behind, the client reconnects from its last event.
+				return
+			}
+			if err := api.writeEvent(w, filter, e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func (api *API) writeEvent(w http.ResponseWriter, filter streamFilter, e bridge.Event) error {
+	if !filter.match(e) {
+		return nil
+	}
+	var data interface{}
+	switch e.Type {
+	case bridge.EventTransfer:
+		data = toTransfer(*e.Transfer)
+	case bridge.EventTVL:
+		data = StreamTVL{
+			Time:     e.Time.UTC(),
+			Network:  string(e.TVL.Network),
+			Symbol:   e.TVL.Symbol,
+			Token:    e.TVL.Token,
+			Value:    e.TVL.Value,
+			ValueUSD: e.TVL.ValueUSD,
+		}
+	case bridge.EventPrice:
+		data = StreamPrice{
+			Time:       e.Time.UTC(),
+			Symbol:     e.Price.Symbol,
+			Source:     e.Price.Source,
+			Price:      e.Price.Value,
+			AgeSeconds: e.Price.Age.Seconds(),
+		}
+	}
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	b, err := json.Marshal(data)
+	if err != nil {
+		level.Error(api.logger).Log("msg", "error marshaling event", "err", err)
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "id: %d-%d\nevent: %s\ndata: %s\n\n", api.store.Events().Epoch(), e.ID, e.Type, b)
+	return err
+}
+
+// parseEventID parses the epoch-id event ids.
+func parseEventID(v string) (int64, uint64, error) {
+	parts := strings.SplitN(v, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, errors.Errorf("event id:%v has no epoch", v)
+	}
+	epoch, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	return epoch, id, err
+}
@@ -8,8 +8,12 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/bridge"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/format"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/health"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/logging"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/metrics"
+	"github.com/IoTube-analytics/go-iotube-analytics/pkg/openapi"
 	"github.com/IoTube-analytics/go-iotube-analytics/pkg/web/api"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -26,6 +30,11 @@ type Config struct {
 	ListenHost  string
 	ListenPort  uint
 	ReadTimeout format.Duration
+	// Query restricts the query endpoint.
+	Query api.QueryConfig
+	Cache api.CacheConfig
+	// Auth of the api keys and rate limits of all endpoints.
+	Auth api.AuthConfig
 }
 
 type Web struct {
@@ -36,25 +45,42 @@ type Web struct {
 	srv    *http.Server
 }
 
-func New(logger log.Logger, ctx context.Context, tsDB influxdb2.Client, cfg Config) (*Web, error) {
+func New(logger log.Logger, ctx context.Context, tsDB influxdb2.Client, store *bridge.Store, health *health.Health, cfg Config) (*Web, error) {
 	logger, err := logging.ApplyFilter(cfg.LogLevel, logger)
 	if err != nil {
 		return nil, errors.Wrap(err, "apply filter logger")
 	}
 	router := route.New()
 
-	api := api.New(logger, ctx, tsDB)
+	api, err := api.New(logger, ctx, tsDB, store, cfg.Query, cfg.Cache, cfg.Auth)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating api")
+	}
 	api.Register(router.WithPrefix("/api/v1"))
 
 	mux := http.NewServeMux()
 
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
+		AllowedHeaders:   []string{"Accept", "Content-Type", "X-Requested-With", "X-API-Key", "X-Admin-Key", "If-None-Match", "Last-Event-ID"},
+		ExposedHeaders:   []string{"ETag", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining"},
 		AllowCredentials: true,
 	})
 	mux.Handle("/", c.Handler(router))
+
+	// Polydefi api component.
+	polydefi, err := openapi.NewHandler(logger, store)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating polydefi api")
+	}
+	mux.Handle(openapi.BasePath, polydefi)
+
+	mux.HandleFunc("/healthz", health.ServeLive)
+	mux.HandleFunc("/readyz", health.ServeReady)
+	mux.Handle("/metrics", metrics.Handler())
+
 	srv := &http.Server{
-		Handler:     mux,
+		Handler:     api.Authenticate(mux),
 		ReadTimeout: cfg.ReadTimeout.Duration,
 		Addr:        fmt.Sprintf("%s:%d", cfg.ListenHost, cfg.ListenPort),
 	}